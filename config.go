@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
+)
+
+// configRefIndex is the name of the Arman informer index keyed by the
+// ConfigMaps and Secrets an Arman references, as returned by configRefKey.
+const configRefIndex = "configRef"
+
+// configRefKey returns the configRefIndex key of the ConfigMap or Secret
+// named name in namespace.
+func configRefKey(namespace, kind, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+// indexByConfigRef indexes an Arman by the ConfigMaps and Secrets it
+// references, so that a change to one of them finds the Armans to roll.
+func indexByConfigRef(obj interface{}) ([]string, error) {
+	arman, ok := obj.(*myv1beta1.Arman)
+	if !ok {
+		return nil, nil
+	}
+	refs := render.References(arman)
+	var keys []string
+	for _, name := range refs.ConfigMaps {
+		keys = append(keys, configRefKey(arman.Namespace, "ConfigMap", name))
+	}
+	for _, name := range refs.Secrets {
+		keys = append(keys, configRefKey(arman.Namespace, "Secret", name))
+	}
+	return keys, nil
+}
+
+// configHash returns the hash of the ConfigMaps and Secrets messi
+// references, and false if it references none. Missing ones are hashed as
+// such, so that their creation rolls the pods too.
+func (c *Controller) configHash(messi *myv1beta1.Arman) (string, bool, error) {
+	refs := render.References(messi)
+	if refs.Empty() {
+		return "", false, nil
+	}
+
+	configMaps := make(map[string]*corev1.ConfigMap, len(refs.ConfigMaps))
+	for _, name := range refs.ConfigMaps {
+		cm, err := c.configMapLister.ConfigMaps(messi.Namespace).Get(name)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", false, err
+		}
+		configMaps[name] = cm
+	}
+	secrets := make(map[string]*corev1.Secret, len(refs.Secrets))
+	for _, name := range refs.Secrets {
+		secret, err := c.secretLister.Secrets(messi.Namespace).Get(name)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", false, err
+		}
+		secrets[name] = secret
+	}
+	return render.ConfigHash(configMaps, secrets), true, nil
+}
+
+// handleConfig enqueues the Armans referencing obj, a ConfigMap or a Secret.
+// It is used for adds, updates and deletes of both.
+func (c *Controller) handleConfig(kind string) func(obj interface{}) {
+	return func(obj interface{}) {
+		var object metav1.Object
+		var ok bool
+		if object, ok = obj.(metav1.Object); !ok {
+			tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+				return
+			}
+			object, ok = tombstone.Obj.(metav1.Object)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
+				return
+			}
+			klog.V(4).Infof("Recovered deleted %s '%s' from tombstone", kind, object.GetName())
+		}
+
+		armans, err := c.armanIndexer.ByIndex(configRefIndex, configRefKey(object.GetNamespace(), kind, object.GetName()))
+		if err != nil {
+			utilruntime.HandleError(err)
+			return
+		}
+		for _, arman := range armans {
+			klog.V(4).Infof("%s '%s/%s' of arman '%s' changed", kind, object.GetNamespace(), object.GetName(), arman.(*myv1beta1.Arman).Name)
+			c.armanAdderFunction(arman)
+		}
+		c.handleObject(object)
+	}
+}
+
+// configUpdateFunc wraps handle for update events, skipping the periodic
+// resyncs of unchanged objects.
+func configUpdateFunc(handle func(obj interface{})) func(old, new interface{}) {
+	return func(old, new interface{}) {
+		if old.(metav1.Object).GetResourceVersion() == new.(metav1.Object).GetResourceVersion() {
+			return
+		}
+		handle(new)
+	}
+}
@@ -18,8 +18,10 @@ package main
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
-	"github.com/sheikh-arman/crd-controller/pkg/client/informers/externalversions/arman.com"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -27,7 +29,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -39,12 +42,15 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
-	myv1alpha1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1alpha1"
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
 	myclientset "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned"
 	myclientscheme "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/scheme"
-	myinformers "github.com/sheikh-arman/crd-controller/pkg/client/informers/externalversions/arman.com/v1alpha1"
-	mylisters "github.com/sheikh-arman/crd-controller/pkg/client/listers/arman.com/v1alpha1"
+	myinformers "github.com/sheikh-arman/crd-controller/pkg/client/informers/externalversions/arman.com/v1beta1"
+	mylisters "github.com/sheikh-arman/crd-controller/pkg/client/listers/arman.com/v1beta1"
+	"github.com/sheikh-arman/crd-controller/pkg/metrics"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
 )
 
 const controllerAgentName = "my-custom-controller"
@@ -62,19 +68,64 @@ const (
 	// MessageResourceSynced is the message used for an Event fired when a messi
 	// is synced successfully
 	MessageResourceSynced = "Arman synced successfully"
+	// ErrResourceConflict is used as part of the Event 'reason' when applying a
+	// child resource conflicts with fields owned by another field manager.
+	ErrResourceConflict = "ResourceConflict"
+	// MessageResourceConflict is the message used for Events when applying a
+	// child resource conflicts with another field manager
+	MessageResourceConflict = "Applying %s %q conflicts with another field manager: %v"
 )
 
+// ConflictPolicy decides what happens when a server-side apply of a child
+// resource conflicts with a field owned by another field manager.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyForce takes over ownership of the conflicting fields.
+	ConflictPolicyForce ConflictPolicy = "Force"
+	// ConflictPolicyReport leaves the conflicting fields to their owner and
+	// reports the conflict as a warning Event on the Arman.
+	ConflictPolicyReport ConflictPolicy = "Report"
+)
+
+// Options holds the settings of a Controller that are not derived from its
+// clients and informers.
+type Options struct {
+	// ConflictPolicy is applied to server-side apply conflicts.
+	ConflictPolicy ConflictPolicy
+	// ShutdownGracePeriod is how long reconciles in progress when the
+	// controller is stopped may take before their API calls are cancelled.
+	ShutdownGracePeriod time.Duration
+	// DryRun sends every write as a server-side dry run and logs what it
+	// would have changed, and keeps Events out of the API.
+	DryRun bool
+	// WorkloadDefaults are the container settings of the cluster used for
+	// the Armans that leave them unset. May be nil.
+	WorkloadDefaults *render.WorkloadDefaults
+}
+
 type DeploymentListerAndSynced struct {
-	deploymentsLister appslisters.DeploymentLister
-	deploymentsSynced cache.InformerSynced
+	deploymentsLister  appslisters.DeploymentLister
+	deploymentsIndexer cache.Indexer
+	deploymentsSynced  cache.InformerSynced
 }
 type ServiceListerAndSynced struct {
-	serviceLister corelisters.ServiceLister
-	serviceSynced cache.InformerSynced
+	serviceLister  corelisters.ServiceLister
+	serviceIndexer cache.Indexer
+	serviceSynced  cache.InformerSynced
 }
 type ArmanListerAndSynced struct {
-	armanLister mylisters.ArmanLister
-	armanSynced cache.InformerSynced
+	armanLister  mylisters.ArmanLister
+	armanIndexer cache.Indexer
+	armanSynced  cache.InformerSynced
+}
+type ConfigListerAndSynced struct {
+	configMapLister  corelisters.ConfigMapLister
+	configMapIndexer cache.Indexer
+	configMapsSynced cache.InformerSynced
+	secretLister     corelisters.SecretLister
+	secretIndexer    cache.Indexer
+	secretsSynced    cache.InformerSynced
 }
 
 // Controller is the controller implementation for messi resources
@@ -87,6 +138,7 @@ type Controller struct {
 	DeploymentListerAndSynced
 	ServiceListerAndSynced
 	ArmanListerAndSynced
+	ConfigListerAndSynced
 
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
@@ -97,13 +149,24 @@ type Controller struct {
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
+
+	// lastWorkerActivity is the time in Unix nanoseconds a worker last asked
+	// the workqueue for an item or got one, used to detect stalled workers.
+	lastWorkerActivity atomic.Int64
+	// clock tells the time for lastWorkerActivity, so that tests can fake it.
+	clock clock.PassiveClock
+
+	Options
 }
 
-func createRecorder(kubeclientset kubernetes.Interface) record.EventRecorder {
+func createRecorder(kubeclientset kubernetes.Interface, dryRun bool) record.EventRecorder {
 	klog.V(4).Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
-	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	// Events of a dry run are only logged.
+	if !dryRun {
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	}
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 	return recorder
 }
@@ -114,31 +177,59 @@ func NewCombo(
 	sampleclientset myclientset.Interface,
 	deploymentInformer appsinformers.DeploymentInformer,
 	serviceInformer coreinformers.ServiceInformer,
-	armanInformer myinformers.ArmanInformer) *Controller {
+	armanInformer myinformers.ArmanInformer,
+	configMapInformer coreinformers.ConfigMapInformer,
+	secretInformer coreinformers.SecretInformer,
+	opts Options) *Controller {
 
 	// Create event broadcaster
 	// Add sample-controller types to the default Kubernetes Scheme so Events can be
 	// logged for sample-controller types.
 	utilruntime.Must(myclientscheme.AddToScheme(scheme.Scheme))
 
+	// Index the children by their controller so that every Deployment and
+	// Service of an Arman can be found, including ones it no longer names.
+	utilruntime.Must(deploymentInformer.Informer().AddIndexers(cache.Indexers{controllerUIDIndex: indexByControllerUID}))
+	utilruntime.Must(serviceInformer.Informer().AddIndexers(cache.Indexers{controllerUIDIndex: indexByControllerUID}))
+	// Index the Armans by the ConfigMaps and Secrets they reference so that a
+	// change to one of them rolls the pods of every Arman using it.
+	utilruntime.Must(armanInformer.Informer().AddIndexers(cache.Indexers{configRefIndex: indexByConfigRef}))
+	// The ConfigMaps and Secrets holding the inline files of an Arman are
+	// children of it too.
+	utilruntime.Must(configMapInformer.Informer().AddIndexers(cache.Indexers{controllerUIDIndex: indexByControllerUID}))
+	utilruntime.Must(secretInformer.Informer().AddIndexers(cache.Indexers{controllerUIDIndex: indexByControllerUID}))
+
 	controller := &Controller{
 		kubeclientset:   kubeclientset,
 		sampleclientset: sampleclientset,
 		DeploymentListerAndSynced: DeploymentListerAndSynced{
-			deploymentsLister: deploymentInformer.Lister(),
-			deploymentsSynced: deploymentInformer.Informer().HasSynced,
+			deploymentsLister:  deploymentInformer.Lister(),
+			deploymentsIndexer: deploymentInformer.Informer().GetIndexer(),
+			deploymentsSynced:  deploymentInformer.Informer().HasSynced,
 		},
 		ServiceListerAndSynced: ServiceListerAndSynced{
-			serviceLister: serviceInformer.Lister(),
-			serviceSynced: serviceInformer.Informer().HasSynced,
+			serviceLister:  serviceInformer.Lister(),
+			serviceIndexer: serviceInformer.Informer().GetIndexer(),
+			serviceSynced:  serviceInformer.Informer().HasSynced,
 		},
 		ArmanListerAndSynced: ArmanListerAndSynced{
-			armanLister: armanInformer.Lister(),
-			armanSynced: armanInformer.Informer().HasSynced,
+			armanLister:  armanInformer.Lister(),
+			armanIndexer: armanInformer.Informer().GetIndexer(),
+			armanSynced:  armanInformer.Informer().HasSynced,
+		},
+		ConfigListerAndSynced: ConfigListerAndSynced{
+			configMapLister:  configMapInformer.Lister(),
+			configMapIndexer: configMapInformer.Informer().GetIndexer(),
+			configMapsSynced: configMapInformer.Informer().HasSynced,
+			secretLister:     secretInformer.Lister(),
+			secretIndexer:    secretInformer.Informer().GetIndexer(),
+			secretsSynced:    secretInformer.Informer().HasSynced,
 		},
 
 		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "armans"),
-		recorder:  createRecorder(kubeclientset),
+		recorder:  createRecorder(kubeclientset, opts.DryRun),
+		clock:     clock.RealClock{},
+		Options:   opts,
 	}
 
 	klog.Info("Setting up event handlers")
@@ -150,14 +241,14 @@ func NewCombo(
 		},
 		DeleteFunc: controller.armanDeleteFunction,
 	})
-	// Set up an event handler for when Deployment resources change. This
-	// handler will lookup the owner of the given Deployment, and if it is
-	// owned by a messi resource then the handler will enqueue that messi resource for
-	// processing. This way, we don't need to implement custom logic for
-	// handling Deployment resources. More info on this pattern:
+	// Set up an event handler for when Deployment and Service resources
+	// change. This handler will lookup the owner of the given object, and if it
+	// is controlled by an Arman resource then the handler will enqueue that
+	// Arman resource for processing. This way, we don't need to implement
+	// custom logic for handling child resources. More info on this pattern:
 	// https://github.com/kubernetes/community/blob/8cafef897a22026d42f5e5bb3f104febe7e29830/contributors/devel/controllers.md
 	deploymentInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: controller.deploymentAdderFunction,
+		AddFunc: controller.handleObject,
 		UpdateFunc: func(old, new interface{}) {
 			newDepl := new.(*appsv1.Deployment)
 			oldDepl := old.(*appsv1.Deployment)
@@ -166,71 +257,120 @@ func NewCombo(
 				// Two different versions of the same Deployment will always have different RVs.
 				return
 			}
-			controller.deploymentAdderFunction(new)
+			controller.handleObject(new)
 		},
-		DeleteFunc: controller.deploymentDeleteFunction,
+		DeleteFunc: controller.handleObject,
 	})
 
 	serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: controller.serviceAdderFunction,
+		AddFunc: controller.handleObject,
 		UpdateFunc: func(old, new interface{}) {
 			newSvc := new.(*corev1.Service)
 			oldSvc := old.(*corev1.Service)
 			if newSvc.ResourceVersion == oldSvc.ResourceVersion {
 				return
 			}
-			controller.serviceAdderFunction(new)
+			controller.handleObject(new)
 		},
-		DeleteFunc: controller.serviceDeleteFunction,
+		DeleteFunc: controller.handleObject,
+	})
+
+	// ConfigMaps and Secrets are not owned by the Armans referencing them,
+	// which are found through the configRefIndex instead. The ones holding
+	// inline files go to the Arman controlling them as well.
+	handleConfigMap := controller.handleConfig("ConfigMap")
+	configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handleConfigMap,
+		UpdateFunc: configUpdateFunc(handleConfigMap),
+		DeleteFunc: handleConfigMap,
+	})
+	handleSecret := controller.handleConfig("Secret")
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handleSecret,
+		UpdateFunc: configUpdateFunc(handleSecret),
+		DeleteFunc: handleSecret,
 	})
 
 	return controller
 }
 
 // Run will set up the event handlers for types we are interested in, as well
-// as syncing informer caches and starting workers. It will block until stopCh
-// is closed, at which point it will shutdown the workqueue and wait for
-// workers to finish processing their current work items.
-func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
-	fmt.Println("Run is called")
+// as syncing informer caches and starting workers. It will block until ctx
+// is done, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items. Work items still
+// being processed once ShutdownGracePeriod has passed have their API calls
+// cancelled.
+func (c *Controller) Run(ctx context.Context, workers int) error {
 	defer utilruntime.HandleCrash()
-	defer c.workqueue.ShutDown()
 
 	// Start the informer factories to begin populating the informer caches
 	klog.Info("Starting arman controller")
 
 	// Wait for the caches to be synced before starting workers
 	klog.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.deploymentsSynced, c.serviceSynced, c.armanSynced); !ok {
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.deploymentsSynced, c.serviceSynced, c.armanSynced, c.configMapsSynced, c.secretsSynced); !ok {
+		c.workqueue.ShutDown()
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	// The reconciles outlive ctx by up to the grace period, so they get a
+	// context of their own.
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
 	klog.Info("Starting workers")
-	// Launch two workers to process messi resources
+	c.markWorkerActivity()
+	var wg sync.WaitGroup
+	// Launch workers to process messi resources
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second*5, stopCh)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.runWorker(workCtx)
+		}()
 	}
 
 	klog.Info("Started workers")
-	<-stopCh
+	<-ctx.Done()
 	klog.Info("Shutting down workers")
 
+	// ShutDownWithDrain waits for the items being processed to be done, and
+	// the workers return once nothing is left on the queue.
+	drained := make(chan struct{})
+	go func() {
+		c.workqueue.ShutDownWithDrain()
+		wg.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(c.ShutdownGracePeriod)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+		klog.Infof("Workers did not finish within %s, cancelling in-flight reconciles", c.ShutdownGracePeriod)
+		cancelWork()
+		<-drained
+	}
+	klog.Info("Workers stopped")
+
 	return nil
 }
 
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
-func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
 	}
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling the syncHandler.
-func (c *Controller) processNextWorkItem() bool {
-	fmt.Println("processNextWorkItem is called")
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	c.markWorkerActivity()
 	obj, shutdown := c.workqueue.Get()
+	c.markWorkerActivity()
 
 	if shutdown {
 		return false
@@ -262,7 +402,12 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// messi resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(ctx, key)
+		result := reconcileResult(err)
+		metrics.ReconcileTotal.WithLabelValues(result).Inc()
+		metrics.ReconcileDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -270,7 +415,7 @@ func (c *Controller) processNextWorkItem() bool {
 		// Finally, if no error occurs we Forget this item so it does not
 		// get queued again until another change happens.
 		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		klog.V(4).Infof("Successfully synced '%s'", key)
 		return nil
 	}(obj)
 
@@ -285,8 +430,7 @@ func (c *Controller) processNextWorkItem() bool {
 // syncHandler compares the actual state with the desired, and attempts to
 // converge the two. It then updates the Status block of the messi resource
 // with the current status of the resource.
-func (c *Controller) syncHandler(key string) error {
-	fmt.Println("syncHandler is called")
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -307,7 +451,23 @@ func (c *Controller) syncHandler(key string) error {
 		return err
 	}
 
-	deploymentName := arman.Spec.DeploymentName
+	// An Arman being deleted only has its children cleaned up according to
+	// its deletion policy. Every other Arman gets the finalizer that makes
+	// this possible.
+	if messi.DeletionTimestamp != nil {
+		return c.finalizeArman(ctx, messi)
+	}
+	if messi, err = c.ensureFinalizer(ctx, messi); err != nil {
+		return err
+	}
+
+	// Armans created without the defaulting webhook may lack fields it would
+	// have set, so the same defaults are applied to what is synced. They are
+	// not written back, and the status subresource ignores the spec.
+	messi = messi.DeepCopy()
+	myclientscheme.Scheme.Default(messi)
+
+	deploymentName := messi.Spec.Workload.Name
 	if deploymentName == "" {
 		// We choose to absorb the error here as the worker would requeue the
 		// resource otherwise. Instead, the next time the resource is updated
@@ -316,7 +476,7 @@ func (c *Controller) syncHandler(key string) error {
 		return nil
 	}
 
-	servicename := arman.Spec.ServiceName
+	servicename := messi.Spec.Service.Name
 	if servicename == "" {
 		// We choose to absorb the error here as the worker would requeue the
 		// resource otherwise. Instead, the next time the resource is updated
@@ -325,80 +485,150 @@ func (c *Controller) syncHandler(key string) error {
 		return nil
 	}
 
-	// Get the deployment with the name specified in messi.spec
-	deployment, err := c.deploymentsLister.Deployments(messi.Namespace).Get(deploymentName)
-	// If the resource doesn't exist, we'll create it
-	if errors.IsNotFound(err) {
-		deployment, err = c.kubeclientset.AppsV1().Deployments(messi.Namespace).Create(context.TODO(), newDeployment(messi), metav1.CreateOptions{})
-	}
+	deployment, svc, err := c.syncChildren(ctx, messi)
 
-	// Get the service with the name specified in messi.spec
-	svc, err := c.serviceLister.Services(messi.Namespace).Get(servicename)
-	// If the resource doesn't exist, we'll create it
-	if errors.IsNotFound(err) {
-		svc, err = c.kubeclientset.CoreV1().Services(messi.Namespace).Create(context.TODO(), newService(messi), metav1.CreateOptions{})
+	// Finally, we update the status block of the messi resource to reflect the
+	// current state of the world. This is done even if syncing the children
+	// failed, so that the failure shows up in the conditions.
+	if statusErr := c.updateArmanStatus(ctx, messi, deployment, svc, err); statusErr != nil {
+		if err != nil {
+			utilruntime.HandleError(statusErr)
+			return err
+		}
+		return statusErr
 	}
-
-	// If an error occurs during Get/Create, we'll requeue the item so we can
-	// attempt processing again later. This could have been caused by a
-	// temporary network failure, or any other transient reason.
+	// A conflict left to the other field manager lasts until that one lets
+	// go of the fields, which changes the child and requeues the Arman, so
+	// it is not retried meanwhile.
+	var conflict applyConflictError
+	if stderrors.As(err, &conflict) && c.ConflictPolicy == ConflictPolicyReport {
+		klog.V(2).Infof("Not retrying arman %s until its children change: %s", key, err.Error())
+		return nil
+	}
+	// If an error occurs, we'll requeue the item so we can attempt processing
+	// again later. This could have been caused by a temporary network failure,
+	// or any other transient reason.
 	if err != nil {
 		return err
 	}
 
-	// If the Deployment is not controlled by this messi resource, we should log
-	// a warning to the event recorder and return error msg.
-	if !metav1.IsControlledBy(deployment, messi) {
-		msg := fmt.Sprintf(MessageResourceExists, deployment.Name)
-		c.recorder.Event(messi, corev1.EventTypeWarning, ErrResourceExists, msg)
-		return fmt.Errorf("%s", msg)
-	}
+	c.recorder.Event(messi, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	return nil
+}
 
-	if !metav1.IsControlledBy(svc, messi) {
-		msg := fmt.Sprintf(MessageResourceExists, svc.Name)
-		c.recorder.Event(messi, corev1.EventTypeWarning, ErrResourceExists, msg)
-		return fmt.Errorf("%s", msg)
+// syncChildren applies the Deployment and Service of messi, and the
+// ConfigMap and Secret holding its inline files. It returns the Deployment
+// and Service if they could be synced, which are nil otherwise.
+func (c *Controller) syncChildren(ctx context.Context, messi *myv1beta1.Arman) (*appsv1.Deployment, *corev1.Service, error) {
+	// If the Deployment exists but is not controlled by this messi resource,
+	// we should log a warning to the event recorder and return error msg.
+	deployment, err := c.deploymentsLister.Deployments(messi.Namespace).Get(messi.Spec.Workload.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, nil, err
 	}
-
-	// If this number of the replicas on the messi resource is specified, and the
-	// number does not equal the current desired replicas on the Deployment, we
-	// should update the Deployment resource.
-	if arman.Spec.Replicas != nil && *messi.Spec.Replicas != *deployment.Spec.Replicas {
-		klog.V(4).Infof("messi %s replicas: %d, deployment replicas: %d", name, *messi.Spec.Replicas, *deployment.Spec.Replicas)
-		deployment, err = c.kubeclientset.AppsV1().Deployments(messi.Namespace).Update(context.TODO(), newDeployment(messi), metav1.UpdateOptions{})
+	if err == nil && !metav1.IsControlledBy(deployment, messi) {
+		err = resourceExistsError{name: deployment.Name}
+		c.recorder.Event(messi, corev1.EventTypeWarning, ErrResourceExists, err.Error())
+		return nil, nil, err
 	}
 
-	// this has not been replicated.
+	svc, err := c.serviceLister.Services(messi.Namespace).Get(messi.Spec.Service.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	if err == nil && !metav1.IsControlledBy(svc, messi) {
+		err = resourceExistsError{name: svc.Name}
+		c.recorder.Event(messi, corev1.EventTypeWarning, ErrResourceExists, err.Error())
+		return nil, nil, err
+	}
 
-	// If an error occurs during Update, we'll requeue the item so we can
-	// attempt processing again later. This could have been caused by a
-	// temporary network failure, or any other transient reason.
+	// Apply the desired Deployment and Service. Server-side apply creates them
+	// if needed and converges every field we own, while fields owned by other
+	// managers, like HPA-managed replicas or the allocated ClusterIP, are left
+	// alone. The pod template carries the hash of the referenced config so
+	// that changing it rolls the pods.
+	if err := c.syncFiles(ctx, messi); err != nil {
+		return nil, nil, err
+	}
+	desired := render.Deployment(c.WorkloadDefaults.Apply(messi))
+	hash, ok, err := c.configHash(messi)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	if ok {
+		render.WithConfigHash(desired, hash)
+	}
+	deployment, err = c.applyDeployment(ctx, messi, desired)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Finally, we update the status block of the messi resource to reflect the
-	// current state of the world
-	err = c.updateArmanStatus(messi, deployment, svc)
+	svc, err = c.applyService(ctx, messi, render.Service(messi))
 	if err != nil {
-		return err
+		return deployment, nil, err
 	}
 
-	c.recorder.Event(messi, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
-	return nil
+	// Only once the current children are in place are the ones left behind
+	// by a rename deleted.
+	if err := c.pruneChildren(ctx, messi); err != nil {
+		return deployment, svc, err
+	}
+	if err := c.pruneFileRevisions(ctx, messi); err != nil {
+		return deployment, svc, err
+	}
+
+	return deployment, svc, nil
+}
+
+// applyOptions returns the options every server-side apply of the controller
+// is sent with.
+func (c *Controller) applyOptions() metav1.ApplyOptions {
+	return metav1.ApplyOptions{
+		FieldManager: controllerAgentName,
+		Force:        c.ConflictPolicy == ConflictPolicyForce,
+		DryRun:       c.dryRun(),
+	}
 }
 
-func (c *Controller) updateArmanStatus(messi *myv1alpha1.Arman, deployment *appsv1.Deployment, svc *corev1.Service) error {
-	// NEVER modify objects from the store. It's a read-only, local cache.
-	// You can use DeepCopy() to make a deep copy of original object and modify this copy
-	// Or create a copy manually for better performance
-	fmt.Println("updateMessiStatus is called")
-	messiCopy := messi.DeepCopy()
-	messiCopy.Status.AvailableReplicas = deployment.Status.AvailableReplicas
-	// If the CustomResourceSubresources feature gate is not enabled,
-	// we must use Update instead of UpdateStatus to update the Status block of the messi resource.
-	// UpdateStatus will not allow changes to the Spec of the resource,
-	// which is ideal for ensuring nothing other than resource status has been updated.
-	_, err := c.sampleclientset.ArmanV1alpha1().Armans(messi.Namespace).Update(context.TODO(), messiCopy, metav1.UpdateOptions{})
-	return err
+// applyDeployment server-side applies the desired Deployment of messi. A
+// conflict with another field manager is reported as an Event.
+func (c *Controller) applyDeployment(ctx context.Context, messi *myv1beta1.Arman, desired *appsv1ac.DeploymentApplyConfiguration) (*appsv1.Deployment, error) {
+	current, getErr := c.deploymentsLister.Deployments(messi.Namespace).Get(*desired.Name)
+	verb := applyVerb(getErr)
+	deployment, err := c.kubeclientset.AppsV1().Deployments(messi.Namespace).Apply(ctx, desired, c.applyOptions())
+	recordChildRequest("Deployment", verb, err)
+	if c.DryRun && err == nil {
+		logDryRun(messi, "Deployment", *desired.Name, current, deployment)
+	}
+	if errors.IsConflict(err) {
+		c.recorder.Eventf(messi, corev1.EventTypeWarning, ErrResourceConflict, MessageResourceConflict, "Deployment", *desired.Name, err)
+		err = applyConflictError{err: err}
+	}
+	return deployment, err
+}
+
+// applyService server-side applies the desired Service of messi. A conflict
+// with another field manager is reported as an Event.
+func (c *Controller) applyService(ctx context.Context, messi *myv1beta1.Arman, desired *corev1ac.ServiceApplyConfiguration) (*corev1.Service, error) {
+	current, getErr := c.serviceLister.Services(messi.Namespace).Get(*desired.Name)
+	verb := applyVerb(getErr)
+	svc, err := c.kubeclientset.CoreV1().Services(messi.Namespace).Apply(ctx, desired, c.applyOptions())
+	recordChildRequest("Service", verb, err)
+	if c.DryRun && err == nil {
+		logDryRun(messi, "Service", *desired.Name, current, svc)
+	}
+	if errors.IsConflict(err) {
+		c.recorder.Eventf(messi, corev1.EventTypeWarning, ErrResourceConflict, MessageResourceConflict, "Service", *desired.Name, err)
+		err = applyConflictError{err: err}
+	}
+	return svc, err
+}
+
+// applyVerb returns whether applying a child creates or updates it, given the
+// error of looking the child up in the informer cache.
+func applyVerb(err error) string {
+	if errors.IsNotFound(err) {
+		return "create"
+	}
+	return "update"
 }
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myfake "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/fake"
+)
+
+// startRun runs c with one worker, as if its caches had synced, and
+// returns the channel Run returns its error on.
+func startRun(ctx context.Context, c *Controller) <-chan error {
+	synced := func() bool { return true }
+	c.deploymentsSynced, c.serviceSynced, c.armanSynced, c.configMapsSynced, c.secretsSynced = synced, synced, synced, synced, synced
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx, 1) }()
+	return done
+}
+
+// blockArmanUpdates makes api hold the updates of Armans, like the one
+// adding the finalizer, until unblock returns. started receives a value
+// once the first one arrived.
+func blockArmanUpdates(api *apiServer, unblock func(r *http.Request) bool) <-chan struct{} {
+	started := make(chan struct{}, 1)
+	api.respond = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.URL.Path, "/apis/arman.com/") {
+			return false
+		}
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		return unblock(r)
+	}
+	return started
+}
+
+// checkWorkersGone fails if a goroutine of Run or of its workers is left.
+func checkWorkersGone(t *testing.T) {
+	t.Helper()
+	var stacks string
+	err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		buf := make([]byte, 1<<20)
+		stacks = string(buf[:goruntime.Stack(buf, true)])
+		return !strings.Contains(stacks, "main.(*Controller).Run") && !strings.Contains(stacks, "main.(*Controller).runWorker"), nil
+	})
+	if err != nil {
+		t.Errorf("goroutines of Run left behind:\n%s", stacks)
+	}
+}
+
+func TestRunDrainsOnShutdown(t *testing.T) {
+	api := newAPIServer(t)
+	release := make(chan struct{})
+	started := blockArmanUpdates(api, func(r *http.Request) bool {
+		<-release
+		return false
+	})
+	c := newAPIController(t, api, Options{ConflictPolicy: ConflictPolicyForce, ShutdownGracePeriod: time.Minute}, newArman("web", armanUID))
+	c.workqueue.Add("default/web")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := startRun(ctx, c)
+	<-started
+	cancel()
+
+	// The reconcile in progress holds Run up.
+	select {
+	case err := <-done:
+		t.Fatalf("Run() = %v while an item was being processed", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	// The reconcile ran to its end, not only to the call it was blocked on.
+	var got []string
+	for _, r := range api.writes() {
+		got = append(got, r.String())
+	}
+	if len(got) == 0 || got[len(got)-1] != "PUT armans/web/status" {
+		t.Errorf("writes = %q, want the reconcile to end with the status update", got)
+	}
+	if n := c.workqueue.Len(); n != 0 {
+		t.Errorf("workqueue holds %d items after the drain, want 0", n)
+	}
+	checkWorkersGone(t)
+}
+
+func TestRunCancelsStuckWorkers(t *testing.T) {
+	api := newAPIServer(t)
+	cancelled := make(chan struct{})
+	started := blockArmanUpdates(api, func(r *http.Request) bool {
+		// The worker is stuck until its request is cancelled.
+		<-r.Context().Done()
+		close(cancelled)
+		return true
+	})
+	c := newAPIController(t, api, Options{ConflictPolicy: ConflictPolicyForce, ShutdownGracePeriod: 100 * time.Millisecond}, newArman("web", armanUID))
+	c.workqueue.Add("default/web")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := startRun(ctx, c)
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run() = %v, want nil", err)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("Run did not return after the grace period")
+	}
+	// The server finds out about the cancellation on its own time.
+	select {
+	case <-cancelled:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Error("the request of the stuck worker was not cancelled")
+	}
+	checkWorkersGone(t)
+}
+
+// syncedArman returns the Arman named web that already has its finalizer.
+func syncedArman() *myv1beta1.Arman {
+	arman := newArman("web", armanUID)
+	arman.Finalizers = []string{myv1beta1.CleanupFinalizer}
+	arman.Spec = myv1beta1.ArmanSpec{
+		Workload: myv1beta1.WorkloadSpec{Name: "web", Image: "registry.example.com/web:v1"},
+		Service:  myv1beta1.ServiceSpec{Name: "web", Port: 80},
+	}
+	return arman
+}
+
+func TestApplyOptions(t *testing.T) {
+	tests := []struct {
+		policy    ConflictPolicy
+		wantForce string
+	}{
+		{ConflictPolicyForce, "true"},
+		{ConflictPolicyReport, "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			api := newAPIServer(t)
+			c := newAPIController(t, api, Options{ConflictPolicy: tt.policy}, syncedArman())
+			if err := c.syncHandler(context.Background(), "default/web"); err != nil {
+				t.Fatalf("syncHandler() = %v", err)
+			}
+
+			var applies int
+			for _, r := range api.writes() {
+				if r.method != http.MethodPatch {
+					continue
+				}
+				applies++
+				if got := r.query.Get("fieldManager"); got != controllerAgentName {
+					t.Errorf("%s sent with field manager %q, want %q", r, got, controllerAgentName)
+				}
+				if got := r.query.Get("force"); got != tt.wantForce {
+					t.Errorf("%s sent with force %q, want %q", r, got, tt.wantForce)
+				}
+			}
+			if applies != 2 {
+				t.Errorf("%d applies sent, want the Deployment and the Service: %q", applies, api.writes())
+			}
+		})
+	}
+}
+
+func TestApplyConflict(t *testing.T) {
+	tests := []struct {
+		policy       ConflictPolicy
+		wantRequeues int
+	}{
+		// The conflicting fields are taken over on the retry.
+		{ConflictPolicyForce, 1},
+		// The conflict lasts until the other field manager lets go of the
+		// fields, which requeues the Arman through the Deployment.
+		{ConflictPolicyReport, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			arman := syncedArman()
+			c := newTestController(t, arman)
+			c.ConflictPolicy = tt.policy
+			recorder := record.NewFakeRecorder(10)
+			c.recorder = recorder
+			armanClient := c.sampleclientset.(*myfake.Clientset)
+			if err := armanClient.Tracker().Add(arman); err != nil {
+				t.Fatal(err)
+			}
+			conflict := errors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web",
+				fmt.Errorf(`Apply failed with 1 conflict: conflict with "kubectl-edit": .spec.replicas`))
+			c.kubeclientset.(*k8sfake.Clientset).PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, conflict
+			})
+
+			c.workqueue.Add("default/web")
+			c.processNextWorkItem(context.Background())
+
+			if got := c.workqueue.NumRequeues("default/web"); got != tt.wantRequeues {
+				t.Errorf("requeues = %d, want %d", got, tt.wantRequeues)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				want := fmt.Sprintf("%s %s "+MessageResourceConflict, corev1.EventTypeWarning, ErrResourceConflict, "Deployment", "web", conflict)
+				if event != want {
+					t.Errorf("event = %q, want %q", event, want)
+				}
+			default:
+				t.Error("no conflict Event recorded")
+			}
+
+			var status *myv1beta1.ArmanStatus
+			for _, action := range armanClient.Actions() {
+				if update, ok := action.(k8stesting.UpdateAction); ok && action.GetSubresource() == "status" {
+					status = &update.GetObject().(*myv1beta1.Arman).Status
+				}
+			}
+			if status == nil {
+				t.Fatal("status not updated")
+			}
+			condition := meta.FindStatusCondition(status.Conditions, myv1beta1.ConditionResourceConflict)
+			if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != ReasonApplyConflict {
+				t.Errorf("ResourceConflict condition = %+v, want True with reason %s", condition, ReasonApplyConflict)
+			}
+		})
+	}
+}
+
+// TestSyncUnsetReplicas checks that the replicas of the Deployment are left
+// out of the apply, and so to others like an HPA, while the Arman does not
+// set them, also once defaulted.
+func TestSyncUnsetReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas *int32
+	}{
+		{"unset", nil},
+		{"set", pointer.Int32(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arman := syncedArman()
+			arman.Spec.Workload.Replicas = tt.replicas
+			c := newTestController(t, arman)
+			if err := c.sampleclientset.(*myfake.Clientset).Tracker().Add(arman); err != nil {
+				t.Fatal(err)
+			}
+			var applied []byte
+			kubeClient := c.kubeclientset.(*k8sfake.Clientset)
+			kubeClient.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				applied = action.(k8stesting.PatchAction).GetPatch()
+				return true, &appsv1.Deployment{ObjectMeta: controlledBy("web", "deployment-uid")}, nil
+			})
+			kubeClient.PrependReactor("patch", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &corev1.Service{ObjectMeta: controlledBy("web", "service-uid")}, nil
+			})
+
+			if err := c.syncHandler(context.Background(), "default/web"); err != nil {
+				t.Fatalf("syncHandler() = %v", err)
+			}
+			deployment := &appsv1.Deployment{}
+			if err := json.Unmarshal(applied, deployment); err != nil {
+				t.Fatalf("decoding applied Deployment %s: %v", applied, err)
+			}
+			if !equality.Semantic.DeepEqual(deployment.Spec.Replicas, tt.replicas) {
+				t.Errorf("applied replicas = %v, want %v", deployment.Spec.Replicas, tt.replicas)
+			}
+		})
+	}
+}
+
+func TestApplyVerb(t *testing.T) {
+	notFound := errors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web")
+	if got := applyVerb(notFound); got != "create" {
+		t.Errorf("applyVerb(%v) = %q, want create", notFound, got)
+	}
+	if got := applyVerb(nil); got != "update" {
+		t.Errorf("applyVerb(nil) = %q, want update", got)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	"github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/validation"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
+)
+
+// loadWorkloadDefaults reads the workload defaults of the cluster from the
+// YAML or JSON file filename. An empty filename means no defaults.
+func loadWorkloadDefaults(filename string) (*render.WorkloadDefaults, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	defaults := &render.WorkloadDefaults{}
+	if err := yaml.UnmarshalStrict(data, defaults); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filename, err)
+	}
+
+	allErrs := validation.ValidateResourceRequirements(&defaults.Resources, field.NewPath("resources"))
+	allErrs = append(allErrs, validation.ValidateProbe(defaults.LivenessProbe, true, field.NewPath("livenessProbe"))...)
+	allErrs = append(allErrs, validation.ValidateProbe(defaults.ReadinessProbe, false, field.NewPath("readinessProbe"))...)
+	allErrs = append(allErrs, validation.ValidateProbe(defaults.StartupProbe, true, field.NewPath("startupProbe"))...)
+	allErrs = append(allErrs, validation.ValidateLifecycle(defaults.Lifecycle, field.NewPath("lifecycle"))...)
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(schema.GroupKind{Kind: "WorkloadDefaults"}, filename, allErrs)
+	}
+	return defaults, nil
+}
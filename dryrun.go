@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// dryRunIgnored are the fields the API server sets on every write, left out
+// of the dry-run diffs so that only what the controller changes shows up.
+var dryRunIgnored = cmp.Options{
+	cmpopts.IgnoreFields(metav1.ObjectMeta{}, "UID", "ResourceVersion", "Generation", "CreationTimestamp", "ManagedFields"),
+	cmpopts.EquateEmpty(),
+}
+
+// dryRun returns the dry-run option every write of the controller is sent
+// with: all stages in dry-run mode, nothing otherwise.
+func (c *Controller) dryRun() []string {
+	if c.DryRun {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+// logDryRun logs the difference between the current state of an object of
+// messi and the one a dry-run write returned. current is nil for an object
+// that would be created.
+func logDryRun(messi *myv1beta1.Arman, kind, name string, current, desired interface{}) {
+	if v := reflect.ValueOf(current); !v.IsValid() || v.Kind() == reflect.Pointer && v.IsNil() {
+		data, err := yaml.Marshal(desired)
+		if err != nil {
+			klog.ErrorS(err, "Dry run: encoding object", "arman", klog.KObj(messi), "kind", kind, "name", name)
+			return
+		}
+		klog.InfoS("Dry run: would create", "arman", klog.KObj(messi), "kind", kind, "name", name, "object", string(data))
+		return
+	}
+
+	diff := cmp.Diff(current, desired, dryRunIgnored)
+	if diff == "" {
+		klog.V(4).InfoS("Dry run: no change", "arman", klog.KObj(messi), "kind", kind, "name", name)
+		return
+	}
+	klog.InfoS("Dry run: would change", "arman", klog.KObj(messi), "kind", kind, "name", name, "diff", diff)
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myclientset "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned"
+	myfake "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/fake"
+	myinformers "github.com/sheikh-arman/crd-controller/pkg/client/informers/externalversions"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
+)
+
+// apiRequest is a request received by an apiServer.
+type apiRequest struct {
+	method string
+	// path is the path of the object below its namespace, like
+	// deployments/web or armans/web/status.
+	path  string
+	query url.Values
+}
+
+func (r apiRequest) String() string {
+	return r.method + " " + r.path
+}
+
+// apiServer is an API server that records the requests it gets and answers
+// every write as if it succeeded, with the object sent. The fake clientsets
+// drop the options of creates, updates and patches, which the requests to
+// it keep.
+type apiServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []apiRequest
+	// respond, if set, answers the requests it returns true for.
+	respond func(w http.ResponseWriter, r *http.Request) bool
+}
+
+func newAPIServer(t *testing.T) *apiServer {
+	t.Helper()
+	api := &apiServer{}
+	api.Server = httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (api *apiServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if i := strings.Index(path, "/namespaces/"); i >= 0 {
+		path = strings.SplitN(path[i+len("/namespaces/"):], "/", 2)[1]
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	if r.Method == http.MethodDelete {
+		// The options of a delete are sent in the body instead.
+		opts := &metav1.DeleteOptions{}
+		if err := json.Unmarshal(body, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query["dryRun"] = opts.DryRun
+	}
+	api.mu.Lock()
+	api.requests = append(api.requests, apiRequest{method: r.Method, path: path, query: query})
+	respond := api.respond
+	api.mu.Unlock()
+	if respond != nil && respond(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodDelete {
+		fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","metadata":{},"status":"Success"}`)
+		return
+	}
+	if r.Method == http.MethodPost {
+		w.WriteHeader(http.StatusCreated)
+	}
+	w.Write(body)
+}
+
+// writes returns the requests received other than reads.
+func (api *apiServer) writes() []apiRequest {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	var writes []apiRequest
+	for _, r := range api.requests {
+		if r.method != http.MethodGet {
+			writes = append(writes, r)
+		}
+	}
+	return writes
+}
+
+// newAPIController returns a controller whose clients talk to api and whose
+// informer caches hold objs.
+func newAPIController(t *testing.T, api *apiServer, opts Options, objs ...runtime.Object) *Controller {
+	t.Helper()
+	cfg := &rest.Config{Host: api.URL}
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	armanClient, err := myclientset.NewForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The informers are never started, so the fake clientsets they list
+	// from are never asked for anything.
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), time.Minute)
+	armanInformerFactory := myinformers.NewSharedInformerFactory(myfake.NewSimpleClientset(), time.Minute)
+
+	c := NewCombo(kubeClient, armanClient,
+		kubeInformerFactory.Apps().V1().Deployments(),
+		kubeInformerFactory.Core().V1().Services(),
+		armanInformerFactory.Arman().V1beta1().Armans(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		opts)
+	t.Cleanup(c.workqueue.ShutDown)
+
+	for _, obj := range objs {
+		var err error
+		switch obj.(type) {
+		case *myv1beta1.Arman:
+			err = c.armanIndexer.Add(obj)
+		case *appsv1.Deployment:
+			err = c.deploymentsIndexer.Add(obj)
+		case *corev1.Service:
+			err = c.serviceIndexer.Add(obj)
+		case *corev1.ConfigMap:
+			err = c.configMapIndexer.Add(obj)
+		case *corev1.Secret:
+			err = c.secretIndexer.Add(obj)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLogs sends the klog output to the returned buffer until the test
+// ends.
+func captureLogs(t *testing.T) *syncBuffer {
+	t.Helper()
+	logs := &syncBuffer{}
+	klog.LogToStderr(false)
+	klog.SetOutput(logs)
+	t.Cleanup(func() {
+		klog.SetOutput(os.Stderr)
+		klog.LogToStderr(true)
+	})
+	return logs
+}
+
+// checkDryRun fails unless every write api received was a dry run.
+func checkDryRun(t *testing.T, api *apiServer) {
+	t.Helper()
+	for _, r := range api.writes() {
+		if got := r.query["dryRun"]; len(got) != 1 || got[0] != metav1.DryRunAll {
+			t.Errorf("%s sent with dryRun %v, want [%s]", r, got, metav1.DryRunAll)
+		}
+		if strings.HasPrefix(r.path, "events") {
+			t.Errorf("%s sent, want Events only logged", r)
+		}
+	}
+}
+
+func TestDryRunSync(t *testing.T) {
+	arman := newArman("web", armanUID)
+	arman.Spec = myv1beta1.ArmanSpec{
+		Workload:    myv1beta1.WorkloadSpec{Name: "web", Image: "registry.example.com/web:v2"},
+		Service:     myv1beta1.ServiceSpec{Name: "web", Port: 80},
+		ConfigFiles: map[string]string{"app.yaml": "debug: true"},
+		SecretFiles: map[string]string{"token": "s3cr3t"},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: controlledBy("web", "deployment-uid"),
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: render.ContainerName, Image: "registry.example.com/web:v1"}},
+			}},
+		},
+	}
+	renamed := &appsv1.Deployment{ObjectMeta: controlledBy("web-old", "renamed-uid")}
+
+	api := newAPIServer(t)
+	logs := captureLogs(t)
+	c := newAPIController(t, api, Options{ConflictPolicy: ConflictPolicyForce, DryRun: true}, arman, deployment, renamed)
+
+	if err := c.syncHandler(context.Background(), "default/web"); err != nil {
+		t.Fatalf("syncHandler() = %v", err)
+	}
+
+	var got []string
+	for _, r := range api.writes() {
+		got = append(got, r.String())
+	}
+	want := []string{
+		"PUT armans/web",
+		"PATCH configmaps/" + render.ConfigFilesName(arman),
+		"PATCH secrets/" + render.SecretFilesName(arman),
+		"PATCH deployments/web",
+		"PATCH services/web",
+		"DELETE deployments/web-old",
+		"PUT armans/web/status",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("writes = %q, want %q", got, want)
+	}
+	checkDryRun(t, api)
+
+	// cmp pads its diffs with non-breaking spaces as well.
+	out := logs.String()
+	for _, want := range []string{
+		// The finalizer the Arman would get.
+		`"Dry run: would change" arman="default/web" kind="Arman" name="web" diff=<[^>]*\+[\s\p{Zs}]+Finalizers:[\s\p{Zs}]+\[\]string\{"` + regexp.QuoteMeta(myv1beta1.CleanupFinalizer) + `"\}`,
+		// The image the Deployment would roll to.
+		`"Dry run: would change" arman="default/web" kind="Deployment" name="web" diff=<[^>]*-[\s\p{Zs}]+Image:[\s\p{Zs}]+"registry.example.com/web:v1"[^>]*\+[\s\p{Zs}]+Image:[\s\p{Zs}]+"registry.example.com/web:v2"`,
+		`"Dry run: would create" arman="default/web" kind="Service" name="web" object=<`,
+		`"Dry run: would delete" kind="Deployment" object="default/web-old"`,
+		`"Dry run: would change" arman="default/web" kind="Arman status" name="web" diff=<[^>]*\+[\s\p{Zs}]+Selector:`,
+	} {
+		if !regexp.MustCompile(want).MatchString(out) {
+			t.Errorf("logs do not match %s:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("logs contain the secret files:\n%s", out)
+	}
+}
+
+func TestDryRunFinalize(t *testing.T) {
+	for _, policy := range []myv1beta1.DeletionPolicy{myv1beta1.DeletionPolicyDelete, myv1beta1.DeletionPolicyOrphan} {
+		t.Run(string(policy), func(t *testing.T) {
+			api := newAPIServer(t)
+			captureLogs(t)
+			c := newAPIController(t, api, Options{ConflictPolicy: ConflictPolicyForce, DryRun: true},
+				deletingArman(policy),
+				&appsv1.Deployment{ObjectMeta: controlledBy("web", "deployment-uid")},
+				&corev1.Service{ObjectMeta: controlledBy("web", "service-uid")},
+				&corev1.ConfigMap{ObjectMeta: controlledBy("web-config-0123456789", "configmap-uid")},
+				&corev1.Secret{ObjectMeta: controlledBy("web-secret-0123456789", "secret-uid")},
+			)
+
+			if err := c.syncHandler(context.Background(), "default/web"); err != nil {
+				t.Fatalf("syncHandler() = %v", err)
+			}
+
+			verb := http.MethodDelete
+			if policy == myv1beta1.DeletionPolicyOrphan {
+				verb = http.MethodPatch
+			}
+			var got []string
+			for _, r := range api.writes() {
+				got = append(got, r.String())
+			}
+			want := []string{
+				verb + " deployments/web",
+				verb + " services/web",
+				verb + " configmaps/web-config-0123456789",
+				verb + " secrets/web-secret-0123456789",
+				"PUT armans/web",
+			}
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("writes = %q, want %q", got, want)
+			}
+			checkDryRun(t, api)
+		})
+	}
+}
+
+// TestDryRunEvents checks that the Events of a dry run are kept out of the
+// API, next to a recorder that is not dry-running and sends them.
+func TestDryRunEvents(t *testing.T) {
+	api := newAPIServer(t)
+	captureLogs(t)
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: api.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	arman := newArman("web", armanUID)
+
+	createRecorder(kubeClient, true).Event(arman, corev1.EventTypeNormal, "DryRun", "dry run")
+	createRecorder(kubeClient, false).Event(arman, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return len(api.writes()) > 0, nil
+	}); err != nil {
+		t.Fatal("the Event of the recorder that is not dry-running was not sent")
+	}
+	// The recorders send their Events concurrently, so give the one of the
+	// dry run the time to arrive if it was sent.
+	time.Sleep(100 * time.Millisecond)
+
+	writes := api.writes()
+	if len(writes) != 1 || writes[0].method != http.MethodPost || !strings.HasPrefix(writes[0].path, "events") {
+		t.Errorf("writes = %q, want the Event of the recorder that is not dry-running only", writes)
+	}
+}
@@ -2,45 +2,50 @@ package main
 
 import (
 	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myclientscheme "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/scheme"
 )
 
 // enqueuemessi takes a messi resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than messi.
 func (c *Controller) armanAdderFunction(obj interface{}) {
-	fmt.Println("messiAdderFunction is called")
 	var key string
 	var err error
 	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
 		utilruntime.HandleError(err)
 		return
 	}
+	klog.V(4).Infof("Queueing Arman %s", key)
 	c.workqueue.Add(key)
 }
 
-// This function is same as MessiAdderFunction except the print message.
+// This function is same as MessiAdderFunction except the log message.
 func (c *Controller) armanDeleteFunction(obj interface{}) {
-	fmt.Println("messiDeleteFunction is called")
 	var key string
 	var err error
 	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
 		utilruntime.HandleError(err)
 		return
 	}
+	klog.V(4).Infof("Queueing deleted Arman %s", key)
 	c.workqueue.Add(key)
 }
 
 // handleObject will take any resource implementing metav1.Object and attempt
-// to find the messi resource that 'owns' it. It does this by looking at the
+// to find the Arman resource that 'owns' it. It does this by looking at the
 // objects metadata.ownerReferences field for an appropriate OwnerReference.
-// It then enqueues that messi resource to be processed. If the object does not
-// have an appropriate OwnerReference, it will simply be skipped.
-func (c *Controller) deploymentAdderFunction(obj interface{}) {
-	fmt.Println("deploymentAdderFunction is called")
+// It then enqueues that Arman resource to be processed. If the object does not
+// have an appropriate OwnerReference, it will simply be skipped. It is used for
+// adds, updates and deletes of both Deployments and Services.
+func (c *Controller) handleObject(obj interface{}) {
 	var object metav1.Object
 	var ok bool
 	if object, ok = obj.(metav1.Object); !ok {
@@ -57,134 +62,39 @@ func (c *Controller) deploymentAdderFunction(obj interface{}) {
 		klog.V(4).Infof("Recovered deleted object '%s' from tombstone", object.GetName())
 	}
 	klog.V(4).Infof("Processing object: %s", object.GetName())
-	if ownerRef := metav1.GetControllerOf(object); ownerRef != nil {
-		// If this object is not owned by a messi, we should not do anything more
-		// with it.
-		if ownerRef.Kind != "Messi" {
-			return
-		}
-
-		messi, err := c.armanLister.Armans(object.GetNamespace()).Get(ownerRef.Name)
-		if err != nil {
-			klog.V(4).Infof("ignoring orphaned object '%s' of messi '%s'", object.GetSelfLink(), ownerRef.Name)
-			return
-		}
-
-		c.armanAdderFunction(messi)
+	ownerRef := metav1.GetControllerOf(object)
+	if ownerRef == nil {
 		return
 	}
-}
-
-// This function is same as DeploymentAdderFunction except the print message.
-func (c *Controller) deploymentDeleteFunction(obj interface{}) {
-	fmt.Println("deploymentDeleteFunction is called")
-	var object metav1.Object
-	var ok bool
-	fmt.Println("Before the 1st if in deplDeleteFunc")
-	if object, ok = obj.(metav1.Object); !ok {
-		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
-		if !ok {
-			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
-			return
-		}
-		object, ok = tombstone.Obj.(metav1.Object)
-		if !ok {
-			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
-			return
-		}
-		klog.V(4).Infof("Recovered deleted object '%s' from tombstone", object.GetName())
-	}
-	fmt.Println("After the 1st if in deplDeleteFunc")
-	klog.V(4).Infof("Processing object: %s", object.GetName())
-	if ownerRef := metav1.GetControllerOf(object); ownerRef != nil {
-		fmt.Println("Inside the 2nd if in deplDeleteFunc")
-		// If this object is not owned by a messi, we should not do anything more
-		// with it.
-		if ownerRef.Kind != "Messi" {
-			return
-		}
-
-		arman, err := c.armanLister.Armans(object.GetNamespace()).Get(ownerRef.Name)
-		if err != nil {
-			klog.V(4).Infof("ignoring orphaned object '%s' of messi '%s'", object.GetSelfLink(), ownerRef.Name)
-			return
-		}
-
-		c.armanDeleteFunction(arman)
+	// If this object is not controlled by an Arman, we should not do anything
+	// more with it.
+	if !isArmanOwner(ownerRef) {
 		return
 	}
-	fmt.Println("Before the 2nd if in deplDeleteFunc")
-}
 
-func (c *Controller) serviceAdderFunction(obj interface{}) {
-	fmt.Println("serviceAdderFunction is called")
-	var object metav1.Object
-	var ok bool
-	if object, ok = obj.(metav1.Object); !ok {
-		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
-		if !ok {
-			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
-			return
-		}
-		object, ok = tombstone.Obj.(metav1.Object)
-		if !ok {
-			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
-			return
-		}
-		klog.V(4).Infof("Recovered deleted object '%s' from tombstone", object.GetName())
-	}
-	klog.V(4).Infof("Processing object: %s", object.GetName())
-	if ownerRef := metav1.GetControllerOf(object); ownerRef != nil {
-		// If this object is not owned by a messi, we should not do anything more
-		// with it.
-		if ownerRef.Kind != "Messi" {
-			return
-		}
-
-		arman, err := c.armanLister.Armans(object.GetNamespace()).Get(ownerRef.Name)
-		if err != nil {
-			klog.V(4).Infof("ignoring orphaned object '%s' of messi '%s'", object.GetSelfLink(), ownerRef.Name)
-			return
-		}
-
-		c.armanAdderFunction(arman)
+	arman, err := c.armanLister.Armans(object.GetNamespace()).Get(ownerRef.Name)
+	if err != nil {
+		klog.V(4).Infof("ignoring orphaned object '%s/%s' of arman '%s'", object.GetNamespace(), object.GetName(), ownerRef.Name)
 		return
 	}
-}
-
-// This function is same as ServiceAdderFunction except the print message.
-func (c *Controller) serviceDeleteFunction(obj interface{}) {
-	fmt.Println("serviceDeleteFunction is called")
-	var object metav1.Object
-	var ok bool
-	if object, ok = obj.(metav1.Object); !ok {
-		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
-		if !ok {
-			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type"))
-			return
-		}
-		object, ok = tombstone.Obj.(metav1.Object)
-		if !ok {
-			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
-			return
-		}
-		klog.V(4).Infof("Recovered deleted object '%s' from tombstone", object.GetName())
+	// An Arman deleted and recreated under the same name does not own the
+	// children of its predecessor.
+	if arman.UID != ownerRef.UID {
+		klog.V(4).Infof("ignoring object '%s/%s' of previous arman '%s' with UID %s", object.GetNamespace(), object.GetName(), ownerRef.Name, ownerRef.UID)
+		return
 	}
-	klog.V(4).Infof("Processing object: %s", object.GetName())
-	if ownerRef := metav1.GetControllerOf(object); ownerRef != nil {
-		// If this object is not owned by a messi, we should not do anything more
-		// with it.
-		if ownerRef.Kind != "Messi" {
-			return
-		}
 
-		messi, err := c.armanLister.Armans(object.GetNamespace()).Get(ownerRef.Name)
-		if err != nil {
-			klog.V(4).Infof("ignoring orphaned object '%s' of messi '%s'", object.GetSelfLink(), ownerRef.Name)
-			return
-		}
+	c.armanAdderFunction(arman)
+}
 
-		c.armanDeleteFunction(messi)
-		return
+// isArmanOwner reports whether ownerRef points at an Arman of any API
+// version the controller knows. Children created for an older version keep
+// its owner reference until they are applied again.
+func isArmanOwner(ownerRef *metav1.OwnerReference) bool {
+	gv, err := schema.ParseGroupVersion(ownerRef.APIVersion)
+	if err != nil {
+		return false
 	}
+	gvk := gv.WithKind(ownerRef.Kind)
+	return gvk.GroupKind() == myv1beta1.Kind("Arman") && myclientscheme.Scheme.Recognizes(gvk)
 }
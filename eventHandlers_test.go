@@ -0,0 +1,262 @@
+package main
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myfake "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/fake"
+	myinformers "github.com/sheikh-arman/crd-controller/pkg/client/informers/externalversions"
+)
+
+const armanUID = types.UID("0b6a5f0e-5a4c-4f5e-9d1c-1d2c3b4a5f60")
+
+func newTestController(t *testing.T, armans ...*myv1beta1.Arman) *Controller {
+	t.Helper()
+	kubeClient := k8sfake.NewSimpleClientset()
+	armanClient := myfake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute)
+	armanInformerFactory := myinformers.NewSharedInformerFactory(armanClient, time.Minute)
+	armanInformer := armanInformerFactory.Arman().V1beta1().Armans()
+
+	c := NewCombo(kubeClient, armanClient,
+		kubeInformerFactory.Apps().V1().Deployments(),
+		kubeInformerFactory.Core().V1().Services(),
+		armanInformer,
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		Options{ConflictPolicy: ConflictPolicyForce})
+	t.Cleanup(c.workqueue.ShutDown)
+
+	for _, arman := range armans {
+		if err := armanInformer.Informer().GetIndexer().Add(arman); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func newArman(name string, uid types.UID) *myv1beta1.Arman {
+	return &myv1beta1.Arman{
+		TypeMeta: metav1.TypeMeta{APIVersion: myv1beta1.SchemeGroupVersion.String(), Kind: "Arman"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			UID:       uid,
+		},
+	}
+}
+
+func ownedBy(apiVersion, kind, name string, uid types.UID, controller bool) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      "child",
+		Namespace: metav1.NamespaceDefault,
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       name,
+			UID:        uid,
+			Controller: &controller,
+		}},
+	}
+}
+
+func TestHandleObject(t *testing.T) {
+	armanAPIVersion := myv1beta1.SchemeGroupVersion.String()
+
+	tests := []struct {
+		name string
+		obj  interface{}
+		want []string
+	}{
+		{
+			name: "deployment controlled by arman",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy(armanAPIVersion, "Arman", "web", armanUID, true)},
+			want: []string{"default/web"},
+		},
+		{
+			name: "service controlled by arman",
+			obj:  &corev1.Service{ObjectMeta: ownedBy(armanAPIVersion, "Arman", "web", armanUID, true)},
+			want: []string{"default/web"},
+		},
+		{
+			name: "deleted deployment from tombstone",
+			obj: cache.DeletedFinalStateUnknown{
+				Key: "default/child",
+				Obj: &appsv1.Deployment{ObjectMeta: ownedBy(armanAPIVersion, "Arman", "web", armanUID, true)},
+			},
+			want: []string{"default/web"},
+		},
+		{
+			name: "deleted service from tombstone",
+			obj: cache.DeletedFinalStateUnknown{
+				Key: "default/child",
+				Obj: &corev1.Service{ObjectMeta: ownedBy(armanAPIVersion, "Arman", "web", armanUID, true)},
+			},
+			want: []string{"default/web"},
+		},
+		{
+			name: "tombstone of an unknown type",
+			obj:  cache.DeletedFinalStateUnknown{Key: "default/child", Obj: "not an object"},
+		},
+		{
+			name: "unknown type",
+			obj:  "not an object",
+		},
+		{
+			name: "no owner",
+			obj:  &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "child", Namespace: metav1.NamespaceDefault}},
+		},
+		{
+			name: "owner is not the controller",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy(armanAPIVersion, "Arman", "web", armanUID, false)},
+		},
+		{
+			name: "owner of another kind",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy(armanAPIVersion, "Messi", "web", armanUID, true)},
+		},
+		{
+			name: "owner of another group",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy("example.com/v1alpha1", "Arman", "web", armanUID, true)},
+		},
+		{
+			name: "owner of the previous version",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy("arman.com/v1alpha1", "Arman", "web", armanUID, true)},
+			want: []string{"default/web"},
+		},
+		{
+			name: "owner of an unknown version",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy("arman.com/v1", "Arman", "web", armanUID, true)},
+		},
+		{
+			name: "owner with invalid api version",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy("arman.com/v1alpha1/extra", "Arman", "web", armanUID, true)},
+		},
+		{
+			name: "owner does not exist",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy(armanAPIVersion, "Arman", "gone", armanUID, true)},
+		},
+		{
+			name: "owner was recreated under the same name",
+			obj:  &appsv1.Deployment{ObjectMeta: ownedBy(armanAPIVersion, "Arman", "web", "3f1e2d4c-0000-4000-8000-000000000000", true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t, newArman("web", armanUID))
+
+			c.handleObject(tt.obj)
+
+			var got []string
+			for c.workqueue.Len() > 0 {
+				item, _ := c.workqueue.Get()
+				got = append(got, item.(string))
+				c.workqueue.Done(item)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("enqueued %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("enqueued %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleConfig(t *testing.T) {
+	web := newArman("web", armanUID)
+	web.Spec.Workload.EnvFrom = []corev1.EnvFromSource{{
+		ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}},
+	}}
+	api := newArman("api", "7c9d2e1f-0000-4000-8000-000000000000")
+	api.Spec.Workload.ConfigMounts = []myv1beta1.ConfigMount{
+		{Name: "settings", MountPath: "/etc/api", ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}}},
+		{Name: "tls", MountPath: "/etc/tls", Secret: &corev1.SecretVolumeSource{SecretName: "tls"}},
+	}
+
+	tests := []struct {
+		name string
+		kind string
+		obj  interface{}
+		want []string
+	}{
+		{
+			name: "configmap referenced by two armans",
+			kind: "ConfigMap",
+			obj:  &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: metav1.NamespaceDefault}},
+			want: []string{"default/api", "default/web"},
+		},
+		{
+			name: "secret referenced by one arman",
+			kind: "Secret",
+			obj:  &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: metav1.NamespaceDefault}},
+			want: []string{"default/api"},
+		},
+		{
+			name: "deleted secret from tombstone",
+			kind: "Secret",
+			obj: cache.DeletedFinalStateUnknown{
+				Key: "default/tls",
+				Obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: metav1.NamespaceDefault}},
+			},
+			want: []string{"default/api"},
+		},
+		{
+			name: "secret named like a referenced configmap",
+			kind: "Secret",
+			obj:  &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: metav1.NamespaceDefault}},
+		},
+		{
+			name: "configmap in another namespace",
+			kind: "ConfigMap",
+			obj:  &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "team-a"}},
+		},
+		{
+			name: "configmap holding the files of an arman",
+			kind: "ConfigMap",
+			obj:  &corev1.ConfigMap{ObjectMeta: ownedBy(myv1beta1.SchemeGroupVersion.String(), "Arman", "web", armanUID, true)},
+			want: []string{"default/web"},
+		},
+		{
+			name: "unreferenced configmap",
+			kind: "ConfigMap",
+			obj:  &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: metav1.NamespaceDefault}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t, web, api)
+
+			c.handleConfig(tt.kind)(tt.obj)
+
+			var got []string
+			for c.workqueue.Len() > 0 {
+				item, _ := c.workqueue.Get()
+				got = append(got, item.(string))
+				c.workqueue.Done(item)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("enqueued %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("enqueued %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
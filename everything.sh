@@ -3,7 +3,7 @@
 # change this fields according to yours
 packagename=github.com/sheikh-arman/crd-controller
 groupname=arman.com
-versionname=v1alpha1
+versionname=v1alpha1,v1beta1
 
 
 depelopmentDir=$(pwd)
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
+)
+
+// syncFiles applies the ConfigMap and Secret holding the inline files of
+// messi, if it has any. They are applied before the Deployment, so that
+// the pods find them when they start.
+func (c *Controller) syncFiles(ctx context.Context, messi *myv1beta1.Arman) error {
+	if desired := render.ConfigFilesConfigMap(messi); desired != nil {
+		current, err := c.configMapLister.ConfigMaps(messi.Namespace).Get(*desired.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && !metav1.IsControlledBy(current, messi) {
+			err = resourceExistsError{name: current.Name}
+			c.recorder.Event(messi, corev1.EventTypeWarning, ErrResourceExists, err.Error())
+			return err
+		}
+		if _, err := c.applyConfigMap(ctx, messi, desired); err != nil {
+			return err
+		}
+	}
+
+	if desired := render.SecretFilesSecret(messi); desired != nil {
+		current, err := c.secretLister.Secrets(messi.Namespace).Get(*desired.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && !metav1.IsControlledBy(current, messi) {
+			err = resourceExistsError{name: current.Name}
+			c.recorder.Event(messi, corev1.EventTypeWarning, ErrResourceExists, err.Error())
+			return err
+		}
+		if _, err := c.applySecret(ctx, messi, desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyConfigMap server-side applies the desired ConfigMap of messi. A
+// conflict with another field manager is reported as an Event.
+func (c *Controller) applyConfigMap(ctx context.Context, messi *myv1beta1.Arman, desired *corev1ac.ConfigMapApplyConfiguration) (*corev1.ConfigMap, error) {
+	current, getErr := c.configMapLister.ConfigMaps(messi.Namespace).Get(*desired.Name)
+	verb := applyVerb(getErr)
+	configMap, err := c.kubeclientset.CoreV1().ConfigMaps(messi.Namespace).Apply(ctx, desired, c.applyOptions())
+	recordChildRequest("ConfigMap", verb, err)
+	if c.DryRun && err == nil {
+		logDryRun(messi, "ConfigMap", *desired.Name, current, configMap)
+	}
+	if errors.IsConflict(err) {
+		c.recorder.Eventf(messi, corev1.EventTypeWarning, ErrResourceConflict, MessageResourceConflict, "ConfigMap", *desired.Name, err)
+		err = applyConflictError{err: err}
+	}
+	return configMap, err
+}
+
+// applySecret server-side applies the desired Secret of messi. A conflict
+// with another field manager is reported as an Event.
+func (c *Controller) applySecret(ctx context.Context, messi *myv1beta1.Arman, desired *corev1ac.SecretApplyConfiguration) (*corev1.Secret, error) {
+	_, getErr := c.secretLister.Secrets(messi.Namespace).Get(*desired.Name)
+	verb := applyVerb(getErr)
+	secret, err := c.kubeclientset.CoreV1().Secrets(messi.Namespace).Apply(ctx, desired, c.applyOptions())
+	recordChildRequest("Secret", verb, err)
+	// The data of a Secret is kept out of the logs.
+	if c.DryRun && err == nil {
+		klog.InfoS("Dry run: would apply", "arman", klog.KObj(messi), "kind", "Secret", "name", *desired.Name)
+	}
+	if errors.IsConflict(err) {
+		c.recorder.Eventf(messi, corev1.EventTypeWarning, ErrResourceConflict, MessageResourceConflict, "Secret", *desired.Name, err)
+		err = applyConflictError{err: err}
+	}
+	return secret, err
+}
+
+// controlledFiles returns every ConfigMap and Secret controlled by messi,
+// which hold its inline files, current or superseded.
+func (c *Controller) controlledFiles(messi *myv1beta1.Arman) ([]*corev1.ConfigMap, []*corev1.Secret, error) {
+	configMapObjs, err := c.configMapIndexer.ByIndex(controllerUIDIndex, string(messi.UID))
+	if err != nil {
+		return nil, nil, err
+	}
+	secretObjs, err := c.secretIndexer.ByIndex(controllerUIDIndex, string(messi.UID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	configMaps := make([]*corev1.ConfigMap, 0, len(configMapObjs))
+	for _, obj := range configMapObjs {
+		configMaps = append(configMaps, obj.(*corev1.ConfigMap))
+	}
+	secrets := make([]*corev1.Secret, 0, len(secretObjs))
+	for _, obj := range secretObjs {
+		secrets = append(secrets, obj.(*corev1.Secret))
+	}
+	return configMaps, secrets, nil
+}
+
+// pruneFileRevisions deletes the ConfigMaps and Secrets controlled by messi
+// that hold superseded versions of its inline files, except for the
+// ConfigRevisionHistoryLimit most recently used of each kind, which a
+// rollback of the Deployment may still mount.
+func (c *Controller) pruneFileRevisions(ctx context.Context, messi *myv1beta1.Arman) error {
+	configMaps, secrets, err := c.controlledFiles(messi)
+	if err != nil {
+		return err
+	}
+
+	var configMapObjs, secretObjs []metav1.Object
+	for _, configMap := range configMaps {
+		configMapObjs = append(configMapObjs, configMap)
+	}
+	for _, secret := range secrets {
+		secretObjs = append(secretObjs, secret)
+	}
+
+	limit := 10
+	if messi.Spec.ConfigRevisionHistoryLimit != nil {
+		limit = int(*messi.Spec.ConfigRevisionHistoryLimit)
+	}
+	stale := supersededRevisions(configMapObjs, render.ConfigFilesName(messi), limit)
+	stale = append(stale, supersededRevisions(secretObjs, render.SecretFilesName(messi), limit)...)
+
+	for _, obj := range stale {
+		kind := childKind(obj)
+		klog.V(4).Infof("Pruning %s %s/%s of arman %s", kind, obj.GetNamespace(), obj.GetName(), messi.Name)
+		if err := c.deleteChild(ctx, obj); err != nil {
+			return err
+		}
+		c.recorder.Eventf(messi, corev1.EventTypeNormal, ChildPruned, MessageChildPruned, kind, obj.GetName())
+	}
+	return nil
+}
+
+// supersededRevisions returns the objects other than the one named current
+// beyond the limit most recently used of them. They are ordered by the
+// generation in their LastUsedGenerationAnnotation; those without one,
+// applied before it was set, count as used least recently. Objects last used
+// by the same generation are ordered by creation.
+func supersededRevisions(objs []metav1.Object, current string, limit int) []metav1.Object {
+	var superseded []metav1.Object
+	for _, obj := range objs {
+		if obj.GetName() != current {
+			superseded = append(superseded, obj)
+		}
+	}
+	if len(superseded) <= limit {
+		return nil
+	}
+
+	sort.Slice(superseded, func(i, j int) bool {
+		gi, gj := lastUsedGeneration(superseded[i]), lastUsedGeneration(superseded[j])
+		if gi != gj {
+			return gi > gj
+		}
+		ti, tj := superseded[i].GetCreationTimestamp(), superseded[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return superseded[i].GetName() < superseded[j].GetName()
+	})
+	return superseded[limit:]
+}
+
+// lastUsedGeneration returns the generation of the Arman that last used
+// obj, or -1 if it is not known.
+func lastUsedGeneration(obj metav1.Object) int64 {
+	generation, err := strconv.ParseInt(obj.GetAnnotations()[myv1beta1.LastUsedGenerationAnnotation], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return generation
+}
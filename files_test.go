@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+func TestSupersededRevisions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	configMap := func(name string, age time.Duration) metav1.Object {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))}}
+	}
+	objs := []metav1.Object{
+		configMap("web-config-3", time.Hour),
+		configMap("web-config-current", 0),
+		configMap("web-config-1", 3*time.Hour),
+		configMap("web-config-2b", 2*time.Hour),
+		configMap("web-config-2a", 2*time.Hour),
+	}
+
+	tests := []struct {
+		name    string
+		current string
+		limit   int
+		want    []string
+	}{
+		{
+			name:    "within the limit",
+			current: "web-config-current",
+			limit:   4,
+		},
+		{
+			name:    "oldest beyond the limit",
+			current: "web-config-current",
+			limit:   2,
+			want:    []string{"web-config-2b", "web-config-1"},
+		},
+		{
+			name:    "no history kept",
+			current: "web-config-current",
+			limit:   0,
+			want:    []string{"web-config-3", "web-config-2a", "web-config-2b", "web-config-1"},
+		},
+		{
+			name:  "files removed from the spec",
+			limit: 3,
+			want:  []string{"web-config-2b", "web-config-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, obj := range supersededRevisions(objs, tt.current, tt.limit) {
+				got = append(got, obj.GetName())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("supersededRevisions() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("supersededRevisions() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSupersededRevisionsReverted(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	configMap := func(name string, age time.Duration, lastUsed string) metav1.Object {
+		obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))}}
+		if lastUsed != "" {
+			obj.Annotations = map[string]string{myv1beta1.LastUsedGenerationAnnotation: lastUsed}
+		}
+		return obj
+	}
+	// The files of generation 1 were reverted to in generation 4, after
+	// those of generations 2 and 3. Generation 5 has files of its own.
+	objs := []metav1.Object{
+		configMap("web-config-gen1", 4*time.Hour, "4"),
+		configMap("web-config-gen2", 3*time.Hour, "2"),
+		configMap("web-config-gen3", 2*time.Hour, "3"),
+		configMap("web-config-gen5", time.Hour, "5"),
+		// Applied before the annotation was set.
+		configMap("web-config-old", 5*time.Hour, ""),
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "reverted files kept",
+			limit: 2,
+			want:  []string{"web-config-gen2", "web-config-old"},
+		},
+		{
+			name:  "files without the annotation pruned first",
+			limit: 3,
+			want:  []string{"web-config-old"},
+		},
+		{
+			name:  "least recently used pruned",
+			limit: 1,
+			want:  []string{"web-config-gen3", "web-config-gen2", "web-config-old"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, obj := range supersededRevisions(objs, "web-config-gen5", tt.limit) {
+				got = append(got, obj.GetName())
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("supersededRevisions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
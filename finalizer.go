@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+const (
+	// ChildDeleted is used as part of the Event 'reason' when a child
+	// resource is deleted along with its Arman.
+	ChildDeleted = "ChildDeleted"
+	// ChildOrphaned is used as part of the Event 'reason' when a child
+	// resource is left running after its Arman is deleted.
+	ChildOrphaned = "ChildOrphaned"
+
+	// MessageChildDeleted is the message used for Events when a child
+	// resource is deleted
+	MessageChildDeleted = "Deleted %s %q as required by deletion policy %s"
+	// MessageChildOrphaned is the message used for Events when a child
+	// resource is orphaned
+	MessageChildOrphaned = "Orphaned %s %q as required by deletion policy %s"
+)
+
+// ensureFinalizer adds the cleanup finalizer to messi if it is missing and
+// returns the up to date Arman.
+func (c *Controller) ensureFinalizer(ctx context.Context, messi *myv1beta1.Arman) (*myv1beta1.Arman, error) {
+	if hasFinalizer(messi, myv1beta1.CleanupFinalizer) {
+		return messi, nil
+	}
+	messiCopy := messi.DeepCopy()
+	messiCopy.Finalizers = append(messiCopy.Finalizers, myv1beta1.CleanupFinalizer)
+	updated, err := c.sampleclientset.ArmanV1beta1().Armans(messi.Namespace).Update(ctx, messiCopy, metav1.UpdateOptions{DryRun: c.dryRun()})
+	if c.DryRun && err == nil {
+		logDryRun(messi, "Arman", messi.Name, &messi.ObjectMeta, &updated.ObjectMeta)
+	}
+	return updated, err
+}
+
+// finalizeArman cleans up the children of an Arman that is being deleted as
+// its deletion policy requires, then removes the cleanup finalizer so the
+// deletion can complete.
+func (c *Controller) finalizeArman(ctx context.Context, messi *myv1beta1.Arman) error {
+	if !hasFinalizer(messi, myv1beta1.CleanupFinalizer) {
+		return nil
+	}
+
+	policy := messi.Spec.DeletionPolicy
+	if policy == "" {
+		policy = myv1beta1.DeletionPolicyDelete
+	}
+
+	// Every child is cleaned up, not only the ones named in the spec.
+	deployments, services, err := c.controlledChildren(messi)
+	if err != nil {
+		return err
+	}
+
+	var deleteDeployment, deleteService bool
+	switch policy {
+	case myv1beta1.DeletionPolicyDelete:
+		deleteDeployment, deleteService = true, true
+	case myv1beta1.DeletionPolicyOrphan:
+		deleteDeployment, deleteService = false, false
+	case myv1beta1.DeletionPolicyRetainService:
+		deleteDeployment, deleteService = true, false
+	default:
+		return fmt.Errorf("unknown deletion policy %q", policy)
+	}
+
+	for _, deployment := range deployments {
+		if err := c.cleanupChild(ctx, messi, policy, deployment, deleteDeployment); err != nil {
+			return err
+		}
+	}
+	for _, svc := range services {
+		if err := c.cleanupChild(ctx, messi, policy, svc, deleteService); err != nil {
+			return err
+		}
+	}
+	// The inline files go with the Deployment mounting them.
+	configMaps, secrets, err := c.controlledFiles(messi)
+	if err != nil {
+		return err
+	}
+	for _, configMap := range configMaps {
+		if err := c.cleanupChild(ctx, messi, policy, configMap, deleteDeployment); err != nil {
+			return err
+		}
+	}
+	for _, secret := range secrets {
+		if err := c.cleanupChild(ctx, messi, policy, secret, deleteDeployment); err != nil {
+			return err
+		}
+	}
+
+	messiCopy := messi.DeepCopy()
+	messiCopy.Finalizers = removeFinalizer(messiCopy.Finalizers, myv1beta1.CleanupFinalizer)
+	updated, err := c.sampleclientset.ArmanV1beta1().Armans(messi.Namespace).Update(ctx, messiCopy, metav1.UpdateOptions{DryRun: c.dryRun()})
+	if c.DryRun && err == nil {
+		logDryRun(messi, "Arman", messi.Name, &messi.ObjectMeta, &updated.ObjectMeta)
+	}
+	return err
+}
+
+// cleanupChild deletes a child controlled by messi, or strips
+// the owner reference to messi from it so it survives the Arman, and reports
+// the outcome as an Event.
+func (c *Controller) cleanupChild(ctx context.Context, messi *myv1beta1.Arman, policy myv1beta1.DeletionPolicy, obj metav1.Object, remove bool) error {
+	kind := childKind(obj)
+	if remove {
+		klog.V(4).Infof("Deleting %s %s/%s of arman %s", kind, obj.GetNamespace(), obj.GetName(), messi.Name)
+		if err := c.deleteChild(ctx, obj); err != nil {
+			return err
+		}
+		c.recorder.Eventf(messi, corev1.EventTypeNormal, ChildDeleted, MessageChildDeleted, kind, obj.GetName(), policy)
+		return nil
+	}
+
+	klog.V(4).Infof("Orphaning %s %s/%s of arman %s", kind, obj.GetNamespace(), obj.GetName(), messi.Name)
+	// Owner references are merged by UID, so this only removes the one
+	// pointing at messi.
+	patch := []byte(fmt.Sprintf(`{"metadata":{"ownerReferences":[{"$patch":"delete","uid":%q}]}}`, messi.UID))
+	opts := metav1.PatchOptions{FieldManager: controllerAgentName, DryRun: c.dryRun()}
+	var patched metav1.Object
+	var err error
+	switch obj.(type) {
+	case *appsv1.Deployment:
+		patched, err = c.kubeclientset.AppsV1().Deployments(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.StrategicMergePatchType, patch, opts)
+	case *corev1.Service:
+		patched, err = c.kubeclientset.CoreV1().Services(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.StrategicMergePatchType, patch, opts)
+	case *corev1.ConfigMap:
+		patched, err = c.kubeclientset.CoreV1().ConfigMaps(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.StrategicMergePatchType, patch, opts)
+	case *corev1.Secret:
+		patched, err = c.kubeclientset.CoreV1().Secrets(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.StrategicMergePatchType, patch, opts)
+	}
+	recordChildRequest(kind, "update", err)
+	if c.DryRun && err == nil {
+		logDryRun(messi, kind, obj.GetName(), obj, patched)
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	c.recorder.Eventf(messi, corev1.EventTypeNormal, ChildOrphaned, MessageChildOrphaned, kind, obj.GetName(), policy)
+	return nil
+}
+
+// deleteChild deletes a child of an Arman. The UID precondition makes sure
+// a replacement created in the meantime is left alone.
+func (c *Controller) deleteChild(ctx context.Context, obj metav1.Object) error {
+	uid := obj.GetUID()
+	opts := metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}, DryRun: c.dryRun()}
+	var err error
+	switch obj.(type) {
+	case *appsv1.Deployment:
+		err = c.kubeclientset.AppsV1().Deployments(obj.GetNamespace()).Delete(ctx, obj.GetName(), opts)
+	case *corev1.Service:
+		err = c.kubeclientset.CoreV1().Services(obj.GetNamespace()).Delete(ctx, obj.GetName(), opts)
+	case *corev1.ConfigMap:
+		err = c.kubeclientset.CoreV1().ConfigMaps(obj.GetNamespace()).Delete(ctx, obj.GetName(), opts)
+	case *corev1.Secret:
+		err = c.kubeclientset.CoreV1().Secrets(obj.GetNamespace()).Delete(ctx, obj.GetName(), opts)
+	}
+	recordChildRequest(childKind(obj), "delete", err)
+	if c.DryRun && err == nil {
+		klog.InfoS("Dry run: would delete", "kind", childKind(obj), "object", klog.KObj(obj))
+	}
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func childKind(obj metav1.Object) string {
+	switch obj.(type) {
+	case *appsv1.Deployment:
+		return "Deployment"
+	case *corev1.Service:
+		return "Service"
+	case *corev1.ConfigMap:
+		return "ConfigMap"
+	case *corev1.Secret:
+		return "Secret"
+	}
+	return fmt.Sprintf("%T", obj)
+}
+
+func hasFinalizer(obj metav1.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(finalizers []string, finalizer string) []string {
+	var result []string
+	for _, f := range finalizers {
+		if f != finalizer {
+			result = append(result, f)
+		}
+	}
+	return result
+}
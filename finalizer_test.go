@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myfake "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/fake"
+)
+
+// controlledBy returns the metadata of a child named name of the Arman
+// named web.
+func controlledBy(name string, uid types.UID) metav1.ObjectMeta {
+	meta := ownedBy(myv1beta1.SchemeGroupVersion.String(), "Arman", "web", armanUID, true)
+	meta.Name = name
+	meta.UID = uid
+	return meta
+}
+
+// addChildren adds objs both to the informer caches of c and to its fake
+// clientset.
+func addChildren(t *testing.T, c *Controller, objs ...runtime.Object) {
+	t.Helper()
+	kubeClient := c.kubeclientset.(*k8sfake.Clientset)
+	for _, obj := range objs {
+		var err error
+		switch obj.(type) {
+		case *appsv1.Deployment:
+			err = c.deploymentsIndexer.Add(obj)
+		case *corev1.Service:
+			err = c.serviceIndexer.Add(obj)
+		case *corev1.ConfigMap:
+			err = c.configMapIndexer.Add(obj)
+		case *corev1.Secret:
+			err = c.secretIndexer.Add(obj)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kubeClient.Tracker().Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writes returns the actions of client other than reads, as verb
+// resource/name.
+func writes(client *k8stesting.Fake) []string {
+	var got []string
+	for _, action := range client.Actions() {
+		switch action := action.(type) {
+		case k8stesting.DeleteActionImpl:
+			got = append(got, fmt.Sprintf("delete %s/%s", action.GetResource().Resource, action.GetName()))
+		case k8stesting.PatchActionImpl:
+			got = append(got, fmt.Sprintf("patch %s/%s", action.GetResource().Resource, action.GetName()))
+		case k8stesting.UpdateActionImpl:
+			got = append(got, fmt.Sprintf("update %s/%s", action.GetResource().Resource, action.GetObject().(metav1.Object).GetName()))
+		}
+	}
+	return got
+}
+
+// deletingArman returns the Arman named web being deleted with policy.
+func deletingArman(policy myv1beta1.DeletionPolicy) *myv1beta1.Arman {
+	arman := newArman("web", armanUID)
+	now := metav1.Now()
+	arman.DeletionTimestamp = &now
+	arman.Finalizers = []string{myv1beta1.CleanupFinalizer}
+	arman.Spec.DeletionPolicy = policy
+	return arman
+}
+
+func TestFinalizeArman(t *testing.T) {
+	tests := []struct {
+		policy      myv1beta1.DeletionPolicy
+		wantWrites  []string
+		wantDeleted []string
+	}{
+		{
+			policy: myv1beta1.DeletionPolicyDelete,
+			wantWrites: []string{
+				"delete deployments/web",
+				"delete services/web",
+				"delete configmaps/web-config-0123456789",
+				"update armans/web",
+			},
+			wantDeleted: []string{"deployments/web", "services/web", "configmaps/web-config-0123456789"},
+		},
+		{
+			policy: myv1beta1.DeletionPolicyOrphan,
+			wantWrites: []string{
+				"patch deployments/web",
+				"patch services/web",
+				"patch configmaps/web-config-0123456789",
+				"update armans/web",
+			},
+		},
+		{
+			policy: myv1beta1.DeletionPolicyRetainService,
+			wantWrites: []string{
+				"delete deployments/web",
+				"patch services/web",
+				"delete configmaps/web-config-0123456789",
+				"update armans/web",
+			},
+			wantDeleted: []string{"deployments/web", "configmaps/web-config-0123456789"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			arman := deletingArman(tt.policy)
+			c := newTestController(t, arman)
+			armanClient := c.sampleclientset.(*myfake.Clientset)
+			if err := armanClient.Tracker().Add(arman); err != nil {
+				t.Fatal(err)
+			}
+			kubeClient := c.kubeclientset.(*k8sfake.Clientset)
+			addChildren(t, c,
+				&appsv1.Deployment{ObjectMeta: controlledBy("web", "deployment-uid")},
+				&corev1.Service{ObjectMeta: controlledBy("web", "service-uid")},
+				&corev1.ConfigMap{ObjectMeta: controlledBy("web-config-0123456789", "configmap-uid")},
+			)
+
+			if err := c.finalizeArman(context.Background(), arman); err != nil {
+				t.Fatalf("finalizeArman() = %v", err)
+			}
+
+			got := append(writes(&kubeClient.Fake), writes(&armanClient.Fake)...)
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantWrites) {
+				t.Errorf("writes = %v, want %v", got, tt.wantWrites)
+			}
+
+			for _, action := range kubeClient.Actions() {
+				switch action := action.(type) {
+				case k8stesting.DeleteActionImpl:
+					// Deletes only hit the child that was looked at, not a
+					// replacement of the same name.
+					preconditions := action.GetDeleteOptions().Preconditions
+					if preconditions == nil || preconditions.UID == nil || *preconditions.UID == "" {
+						t.Errorf("delete of %s/%s has no UID precondition", action.GetResource().Resource, action.GetName())
+					}
+				case k8stesting.PatchActionImpl:
+					if action.GetPatchType() != types.StrategicMergePatchType {
+						t.Errorf("patch of %s/%s is a %s, want a strategic merge patch", action.GetResource().Resource, action.GetName(), action.GetPatchType())
+					}
+					var patch map[string]interface{}
+					if err := json.Unmarshal(action.GetPatch(), &patch); err != nil {
+						t.Fatal(err)
+					}
+					want := map[string]interface{}{"metadata": map[string]interface{}{"ownerReferences": []interface{}{
+						map[string]interface{}{"$patch": "delete", "uid": string(armanUID)},
+					}}}
+					if fmt.Sprint(patch) != fmt.Sprint(want) {
+						t.Errorf("patch of %s/%s = %s, want %v", action.GetResource().Resource, action.GetName(), action.GetPatch(), want)
+					}
+				}
+			}
+
+			// Orphaned children are left without the owner reference.
+			svc, err := kubeClient.CoreV1().Services(metav1.NamespaceDefault).Get(context.Background(), "web", metav1.GetOptions{})
+			if tt.policy == myv1beta1.DeletionPolicyDelete {
+				if err == nil {
+					t.Errorf("service still exists")
+				}
+			} else if err != nil {
+				t.Errorf("getting service: %v", err)
+			} else if len(svc.OwnerReferences) != 0 {
+				t.Errorf("orphaned service has owner references %v", svc.OwnerReferences)
+			}
+
+			updated, err := armanClient.ArmanV1beta1().Armans(metav1.NamespaceDefault).Get(context.Background(), "web", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hasFinalizer(updated, myv1beta1.CleanupFinalizer) {
+				t.Errorf("finalizer not removed")
+			}
+		})
+	}
+}
+
+func TestFinalizeArmanCleanupFailed(t *testing.T) {
+	arman := deletingArman(myv1beta1.DeletionPolicyDelete)
+	c := newTestController(t, arman)
+	armanClient := c.sampleclientset.(*myfake.Clientset)
+	if err := armanClient.Tracker().Add(arman); err != nil {
+		t.Fatal(err)
+	}
+	kubeClient := c.kubeclientset.(*k8sfake.Clientset)
+	addChildren(t, c,
+		&appsv1.Deployment{ObjectMeta: controlledBy("web", "deployment-uid")},
+		&corev1.Service{ObjectMeta: controlledBy("web", "service-uid")},
+	)
+	kubeClient.PrependReactor("delete", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("connection refused")
+	})
+
+	if err := c.finalizeArman(context.Background(), arman); err == nil {
+		t.Fatal("finalizeArman() succeeded, want the error of deleting the service")
+	}
+
+	// The finalizer stays until every child is cleaned up.
+	if got := writes(&armanClient.Fake); len(got) != 0 {
+		t.Errorf("arman writes = %v, want none", got)
+	}
+	updated, err := armanClient.ArmanV1beta1().Armans(metav1.NamespaceDefault).Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasFinalizer(updated, myv1beta1.CleanupFinalizer) {
+		t.Errorf("finalizer removed although the service was not deleted")
+	}
+}
+
+func TestFinalizeArmanWithoutFinalizer(t *testing.T) {
+	arman := deletingArman(myv1beta1.DeletionPolicyDelete)
+	arman.Finalizers = nil
+	c := newTestController(t, arman)
+	addChildren(t, c, &appsv1.Deployment{ObjectMeta: controlledBy("web", "deployment-uid")})
+
+	if err := c.finalizeArman(context.Background(), arman); err != nil {
+		t.Fatalf("finalizeArman() = %v", err)
+	}
+	if got := writes(&c.kubeclientset.(*k8sfake.Clientset).Fake); len(got) != 0 {
+		t.Errorf("writes = %v, want none", got)
+	}
+}
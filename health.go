@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// markWorkerActivity records that a worker is about to take an item off the
+// workqueue or just took one.
+func (c *Controller) markWorkerActivity() {
+	c.lastWorkerActivity.Store(c.clock.Now().UnixNano())
+}
+
+// checkWorkers fails if the workqueue holds items but no worker took one off
+// it or asked for one for longer than stallTimeout. An idle worker is always
+// waiting on the queue, so items only pile up like this when every worker is
+// stuck.
+func (c *Controller) checkWorkers(stallTimeout time.Duration) error {
+	last := c.lastWorkerActivity.Load()
+	if last == 0 {
+		// The workers have not been started yet.
+		return nil
+	}
+	if depth := c.workqueue.Len(); depth > 0 {
+		if idle := c.clock.Since(time.Unix(0, last)); idle > stallTimeout {
+			return fmt.Errorf("workers stalled: %d items queued and no item dequeued for %s", depth, idle.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// probeHandler responds with 200 while check passes and with 503 and the
+// error otherwise.
+func probeHandler(check func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}
+}
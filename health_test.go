@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestCheckWorkers(t *testing.T) {
+	const stallTimeout = time.Minute
+
+	tests := []struct {
+		name    string
+		started bool
+		queued  int
+		idle    time.Duration
+		wantErr string
+	}{
+		{
+			name:   "workers not started",
+			queued: 3,
+			idle:   time.Hour,
+		},
+		{
+			name:    "queue empty",
+			started: true,
+			idle:    time.Hour,
+		},
+		{
+			name:    "queued and recently active",
+			started: true,
+			queued:  3,
+			idle:    30 * time.Second,
+		},
+		{
+			name:    "queued and active at the timeout",
+			started: true,
+			queued:  3,
+			idle:    stallTimeout,
+		},
+		{
+			name:    "queued and stalled",
+			started: true,
+			queued:  3,
+			idle:    stallTimeout + time.Second,
+			wantErr: "workers stalled: 3 items queued and no item dequeued for 1m1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			fakeClock := testingclock.NewFakePassiveClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			c.clock = fakeClock
+			if tt.started {
+				c.markWorkerActivity()
+			}
+			for i := 0; i < tt.queued; i++ {
+				c.workqueue.Add(string(rune('a' + i)))
+			}
+			fakeClock.SetTime(fakeClock.Now().Add(tt.idle))
+
+			err := c.checkWorkers(stallTimeout)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkWorkers() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkWorkers() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckWorkersRecovers(t *testing.T) {
+	c := newTestController(t)
+	fakeClock := testingclock.NewFakePassiveClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.clock = fakeClock
+	c.markWorkerActivity()
+	c.workqueue.Add("default/web")
+
+	fakeClock.SetTime(fakeClock.Now().Add(2 * time.Minute))
+	if err := c.checkWorkers(time.Minute); err == nil {
+		t.Fatal("checkWorkers() = nil, want the workers reported as stalled")
+	}
+
+	// A worker getting unstuck takes the next item off the queue.
+	c.markWorkerActivity()
+	if err := c.checkWorkers(time.Minute); err != nil {
+		t.Errorf("checkWorkers() = %v after a worker dequeued, want nil", err)
+	}
+}
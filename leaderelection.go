@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// serviceAccountNamespaceFile holds the namespace of the pod when running
+// in-cluster.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// LeaderElectionOptions configures the Lease based leader election between
+// replicas of the controller.
+type LeaderElectionOptions struct {
+	// LeaseName and LeaseNamespace identify the coordination.k8s.io Lease used
+	// as the lock.
+	LeaseName      string
+	LeaseNamespace string
+	// LeaseDuration is how long followers wait before trying to take over a
+	// lease that was not renewed.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader keeps retrying to renew the lease
+	// before giving it up.
+	RenewDeadline time.Duration
+	// RetryPeriod is the wait between two attempts to acquire or renew the
+	// lease.
+	RetryPeriod time.Duration
+}
+
+// Validate checks the durations the way the leader elector does, so that bad
+// flags are reported instead of making it panic.
+func (o LeaderElectionOptions) Validate() error {
+	if o.LeaseName == "" || o.LeaseNamespace == "" {
+		return fmt.Errorf("lease name and namespace must be set")
+	}
+	if o.LeaseDuration <= o.RenewDeadline {
+		return fmt.Errorf("lease duration %s must be greater than renew deadline %s", o.LeaseDuration, o.RenewDeadline)
+	}
+	if o.RenewDeadline <= time.Duration(leaderelection.JitterFactor*float64(o.RetryPeriod)) {
+		return fmt.Errorf("renew deadline %s must be greater than %v times retry period %s", o.RenewDeadline, leaderelection.JitterFactor, o.RetryPeriod)
+	}
+	if o.RetryPeriod <= 0 {
+		return fmt.Errorf("retry period %s must be positive", o.RetryPeriod)
+	}
+	return nil
+}
+
+// runWithLeaderElection blocks until ctx is done, calling run with a context
+// that is cancelled as soon as leadership is lost once this replica becomes
+// the leader. It returns after run did, so the caller can exit rather than
+// work without holding the lease.
+func runWithLeaderElection(ctx context.Context, kubeClient kubernetes.Interface, opts LeaderElectionOptions, run func(ctx context.Context)) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.Fatalf("Error getting hostname: %s", err.Error())
+	}
+	// The UID keeps the identity unique when two replicas share a hostname.
+	id := hostname + "_" + string(uuid.NewUUID())
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      opts.LeaseName,
+			Namespace: opts.LeaseNamespace,
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	}
+
+	// The election only stops once run returned, so that the lease is not
+	// released to another replica while reconciles are still draining.
+	electionCtx, cancelElection := context.WithCancel(context.Background())
+	defer cancelElection()
+
+	// OnStartedLeading is called on its own goroutine, so run is handed over
+	// and called here instead, where we can wait for it to return.
+	elected := make(chan context.Context, 1)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		leaderelection.RunOrDie(electionCtx, leaderelection.LeaderElectionConfig{
+			Lock:          lock,
+			LeaseDuration: opts.LeaseDuration,
+			RenewDeadline: opts.RenewDeadline,
+			RetryPeriod:   opts.RetryPeriod,
+			// Give the lease up on shutdown so another replica can take over
+			// without waiting for it to expire.
+			ReleaseOnCancel: true,
+			Name:            opts.LeaseName,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					klog.Infof("Acquired lease %s/%s as %s", opts.LeaseNamespace, opts.LeaseName, id)
+					elected <- leaderCtx
+				},
+				OnStoppedLeading: func() {
+					klog.Infof("Stopped leading lease %s/%s as %s", opts.LeaseNamespace, opts.LeaseName, id)
+				},
+				OnNewLeader: func(identity string) {
+					if identity != id {
+						klog.Infof("Lease %s/%s is held by %s", opts.LeaseNamespace, opts.LeaseName, identity)
+					}
+				},
+			},
+		})
+	}()
+
+	select {
+	case leaderCtx := <-elected:
+		runCtx, cancelRun := context.WithCancel(leaderCtx)
+		go func() {
+			select {
+			case <-ctx.Done():
+				cancelRun()
+			case <-runCtx.Done():
+			}
+		}()
+		run(runCtx)
+		cancelRun()
+	case <-ctx.Done():
+	case <-stopped:
+		// Leadership was lost right away.
+	}
+	cancelElection()
+	<-stopped
+}
+
+// defaultLeaseNamespace returns the namespace the controller runs in when
+// in-cluster, and "default" otherwise.
+func defaultLeaseNamespace() string {
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if ns := strings.TrimSpace(string(data)); ns != "" {
+			return ns
+		}
+	}
+	return metav1.NamespaceDefault
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeaderElectionOptionsValidate(t *testing.T) {
+	valid := LeaderElectionOptions{
+		LeaseName:      "arman-controller",
+		LeaseNamespace: "kube-system",
+		LeaseDuration:  15 * time.Second,
+		RenewDeadline:  10 * time.Second,
+		RetryPeriod:    2 * time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(o *LeaderElectionOptions)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(o *LeaderElectionOptions) {},
+		},
+		{
+			name:    "no lease name",
+			modify:  func(o *LeaderElectionOptions) { o.LeaseName = "" },
+			wantErr: "lease name and namespace must be set",
+		},
+		{
+			name:    "no lease namespace",
+			modify:  func(o *LeaderElectionOptions) { o.LeaseNamespace = "" },
+			wantErr: "lease name and namespace must be set",
+		},
+		{
+			name:    "lease duration equal to renew deadline",
+			modify:  func(o *LeaderElectionOptions) { o.LeaseDuration = o.RenewDeadline },
+			wantErr: "lease duration 10s must be greater than renew deadline 10s",
+		},
+		{
+			name:    "lease duration below renew deadline",
+			modify:  func(o *LeaderElectionOptions) { o.LeaseDuration = 5 * time.Second },
+			wantErr: "lease duration 5s must be greater than renew deadline 10s",
+		},
+		{
+			name:    "renew deadline equal to jittered retry period",
+			modify:  func(o *LeaderElectionOptions) { o.RenewDeadline, o.RetryPeriod = 12*time.Second, 10*time.Second },
+			wantErr: "renew deadline 12s must be greater than 1.2 times retry period 10s",
+		},
+		{
+			name: "renew deadline just above jittered retry period",
+			modify: func(o *LeaderElectionOptions) {
+				o.RenewDeadline, o.RetryPeriod = 12*time.Second+time.Millisecond, 10*time.Second
+			},
+		},
+		{
+			name:    "zero retry period",
+			modify:  func(o *LeaderElectionOptions) { o.RetryPeriod = 0 },
+			wantErr: "retry period 0s must be positive",
+		},
+		{
+			name:    "negative retry period",
+			modify:  func(o *LeaderElectionOptions) { o.RetryPeriod = -time.Second },
+			wantErr: "retry period -1s must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := valid
+			tt.modify(&o)
+			err := o.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLeaderElectionFlagDefaults makes sure the controller starts with
+// leader election enabled and no other leader election flags.
+func TestLeaderElectionFlagDefaults(t *testing.T) {
+	if err := leaderElection.Validate(); err != nil {
+		t.Errorf("default flags do not validate: %v", err)
+	}
+}
@@ -1,36 +1,238 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
-	"k8s.io/client-go/informers"
+	"net/http"
+	"os"
+	"time"
+
+	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
-	"time"
+	"k8s.io/klog/v2"
+
+	myclientset "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned"
+	myinformers "github.com/sheikh-arman/crd-controller/pkg/client/informers/externalversions"
+	"github.com/sheikh-arman/crd-controller/pkg/metrics"
+	"github.com/sheikh-arman/crd-controller/pkg/signals"
+	"github.com/sheikh-arman/crd-controller/pkg/webhook"
+)
+
+var (
+	masterURL      string
+	kubeconfig     string
+	workers        int
+	resyncPeriod   time.Duration
+	qps            float64
+	burst          int
+	conflictPolicy string
+
+	leaderElect    bool
+	leaderElection LeaderElectionOptions
+
+	metricsBindAddress     string
+	healthProbeBindAddress string
+	workerStallTimeout     time.Duration
+	shutdownGracePeriod    time.Duration
+	webhookBindAddress     string
+	webhookCertDir         string
+	dryRun                 bool
+	workloadDefaultsFile   string
 )
 
 func main() {
-	kubeconfig := flag.String("kubeconfig", "/home/user/.kube/config", "location to your kubeconfig file")
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
-	if err != nil {
-		fmt.Printf("error %s building config from flags\n", err.Error())
-		config, err = rest.InClusterConfig()
-		if err != nil {
-			fmt.Printf("error %s, getting inclusterconfig", err.Error())
+	klog.InitFlags(nil)
+	flag.Parse()
+	defer klog.Flush()
+
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "migrate":
+			if err := runMigrate(flag.Args()[1:]); err != nil {
+				klog.Fatalf("Error migrating armans: %s", err.Error())
+			}
+			return
+		case "render":
+			if err := runRender(flag.Args()[1:]); err != nil {
+				klog.Fatalf("Error rendering armans: %s", err.Error())
+			}
+			return
+		default:
+			klog.Fatalf("Unknown subcommand %q, must be migrate or render", flag.Arg(0))
 		}
 	}
-	clientset, err := kubernetes.NewForConfig(config)
+
+	switch ConflictPolicy(conflictPolicy) {
+	case ConflictPolicyForce, ConflictPolicyReport:
+	default:
+		klog.Fatalf("Invalid --apply-conflict-policy %q, must be %s or %s", conflictPolicy, ConflictPolicyForce, ConflictPolicyReport)
+	}
+	if leaderElect {
+		if err := leaderElection.Validate(); err != nil {
+			klog.Fatalf("Invalid leader election flags: %s", err.Error())
+		}
+		// A dry run is meant to run next to the controller in charge, which it
+		// must not take the lease from.
+		if dryRun {
+			klog.Fatal("--dry-run cannot be combined with --leader-elect")
+		}
+	}
+
+	workloadDefaults, err := loadWorkloadDefaults(workloadDefaultsFile)
 	if err != nil {
-		fmt.Printf("error %s, creating clientset\n", err.Error())
+		klog.Fatalf("Error loading --workload-defaults: %s", err.Error())
 	}
-	ch := make(chan struct{})
-	informers := informers.NewSharedInformerFactory(clientset, 10*time.Minute)
-	c := newController(clientset, informers.Apps().V1().Deployments())
-	informers.Start(ch)
-	c.run(ch)
+
+	// set up signals so we handle the shutdown signal gracefully
+	ctx := signals.SetupSignalHandler()
+
+	// With both masterURL and kubeconfig empty this falls back to the
+	// in-cluster config.
+	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
+	if err != nil {
+		klog.Fatalf("Error building kubeconfig: %s", err.Error())
+	}
+	cfg.QPS = float32(qps)
+	cfg.Burst = burst
+
+	kubeClient, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
-		fmt.Printf("getting informer factory %s\n", err.Error())
+		klog.Fatalf("Error building kubernetes clientset: %s", err.Error())
 	}
-	fmt.Println(informers)
+
+	armanClient, err := myclientset.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("Error building arman clientset: %s", err.Error())
+	}
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, resyncPeriod)
+	armanInformerFactory := myinformers.NewSharedInformerFactory(armanClient, resyncPeriod)
+
+	deploymentInformer := kubeInformerFactory.Apps().V1().Deployments()
+	serviceInformer := kubeInformerFactory.Core().V1().Services()
+	armanInformer := armanInformerFactory.Arman().V1beta1().Armans()
+	configMapInformer := kubeInformerFactory.Core().V1().ConfigMaps()
+	secretInformer := kubeInformerFactory.Core().V1().Secrets()
+
+	// The workqueue of the controller picks the provider up when it is created.
+	metrics.RegisterWorkqueueProvider()
+	controller := NewCombo(kubeClient, armanClient,
+		deploymentInformer,
+		serviceInformer,
+		armanInformer,
+		configMapInformer,
+		secretInformer,
+		Options{
+			ConflictPolicy:      ConflictPolicy(conflictPolicy),
+			ShutdownGracePeriod: shutdownGracePeriod,
+			DryRun:              dryRun,
+			WorkloadDefaults:    workloadDefaults,
+		})
+
+	metrics.Registry.MustRegister(
+		metrics.NewArmanCollector(armanInformer.Lister()),
+		metrics.NewInformerSyncedCollector(map[string]cache.InformerSynced{
+			"deployments": deploymentInformer.Informer().HasSynced,
+			"services":    serviceInformer.Informer().HasSynced,
+			"armans":      armanInformer.Informer().HasSynced,
+			"configmaps":  configMapInformer.Informer().HasSynced,
+			"secrets":     secretInformer.Informer().HasSynced,
+		}),
+	)
+
+	run := func(ctx context.Context) {
+		// Start is non-blocking and runs every registered informer in its own
+		// goroutine, so both factories must be started before Run blocks.
+		kubeInformerFactory.Start(ctx.Done())
+		armanInformerFactory.Start(ctx.Done())
+
+		if err := controller.Run(ctx, workers); err != nil {
+			// Caches that stop syncing because of a signal or lost
+			// leadership are a shutdown, not a failure.
+			if ctx.Err() != nil {
+				klog.Infof("Controller stopped before the caches synced: %s", err.Error())
+				return
+			}
+			klog.Fatalf("Error running controller: %s", err.Error())
+		}
+	}
+
+	// Metrics, probes and webhooks are served by every replica, not only the
+	// leader. Admission and conversion requests must be answered while no
+	// replica leads or has synced its caches, as the API server needs the
+	// conversion webhook to list the Armans the caches are filled from, so a
+	// replica is ready as soon as it serves webhooks; leadership and synced
+	// caches only gate the reconcile loop.
+	var webhookServer *webhook.Server
+	if webhookBindAddress != "0" {
+		webhookServer = webhook.NewServer(webhookBindAddress, webhookCertDir)
+		webhookServer.Handle(webhook.DefaultPath, webhook.DefaultingHandler())
+		webhookServer.Handle(webhook.ValidatePath, webhook.ValidatingHandler())
+		webhookServer.Handle(webhook.ConvertPath, webhook.ConversionHandler())
+		go func() {
+			if err := webhookServer.Run(ctx); err != nil {
+				klog.Fatalf("Error serving webhooks: %s", err.Error())
+			}
+		}()
+	}
+	if metricsBindAddress != "0" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		go serveHTTP(ctx, "metrics", metricsBindAddress, mux)
+	}
+	if healthProbeBindAddress != "0" {
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", probeHandler(func() error {
+			return controller.checkWorkers(workerStallTimeout)
+		}))
+		mux.Handle("/readyz", probeHandler(func() error {
+			if webhookServer == nil {
+				return nil
+			}
+			return webhookServer.CheckServing()
+		}))
+		go serveHTTP(ctx, "health probes", healthProbeBindAddress, mux)
+	}
+
+	if leaderElect {
+		// Only the leader starts the informers and workers; the other
+		// replicas just wait to take the lease over. Once leadership is lost
+		// the controller stops and the process exits to be restarted as a
+		// follower.
+		runWithLeaderElection(ctx, kubeClient, leaderElection, run)
+	} else {
+		run(ctx)
+	}
+
+	// Run only returns once ctx is done, so the factories are already
+	// stopping; wait for their goroutines before exiting.
+	kubeInformerFactory.Shutdown()
+	armanInformerFactory.Shutdown()
+	klog.Info("Controller stopped")
+}
+
+func init() {
+	flag.StringVar(&kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to a kubeconfig. Defaults to $KUBECONFIG; leave empty to use the in-cluster config.")
+	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.IntVar(&workers, "workers", 2, "Number of workers processing Arman resources concurrently.")
+	flag.DurationVar(&resyncPeriod, "resync-period", 10*time.Minute, "Resync period of the shared informers.")
+	flag.Float64Var(&qps, "kube-api-qps", 20, "Maximum queries per second to the Kubernetes API server.")
+	flag.IntVar(&burst, "kube-api-burst", 30, "Maximum burst of queries to the Kubernetes API server.")
+	flag.StringVar(&conflictPolicy, "apply-conflict-policy", string(ConflictPolicyForce), "What to do when applying a child resource conflicts with another field manager: Force takes the fields over, Report leaves them, records a warning Event and the ResourceConflict condition, and does not retry until the child or the Arman changes.")
+	flag.DurationVar(&shutdownGracePeriod, "shutdown-grace-period", 30*time.Second, "How long reconciles in progress on shutdown may take before their API calls are cancelled.")
+	flag.StringVar(&metricsBindAddress, "metrics-bind-address", ":8080", "The address /metrics is served on. Set to 0 to disable serving metrics.")
+	flag.StringVar(&healthProbeBindAddress, "health-probe-bind-address", ":8081", "The address /healthz and /readyz are served on. Set to 0 to disable serving probes.")
+	flag.DurationVar(&workerStallTimeout, "worker-stall-timeout", 5*time.Minute, "How long the workqueue may hold items without any being dequeued before /healthz fails.")
+	flag.StringVar(&webhookBindAddress, "webhook-bind-address", "0", "The address the admission and conversion webhooks are served on over TLS, like :9443. Set to 0 to disable serving webhooks.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Directory holding the tls.crt and tls.key the webhooks are served with.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Send every write to the API server as a server-side dry run and log the difference between the current and the resulting objects, without changing anything. Events are only logged.")
+	flag.StringVar(&workloadDefaultsFile, "workload-defaults", "", "YAML file holding the resources, livenessProbe, readinessProbe, startupProbe and lifecycle used for the containers of the Armans that do not set them.")
+	flag.BoolVar(&leaderElect, "leader-elect", false, "Elect a leader among the replicas of the controller through a Lease before starting, so that only one of them reconciles at a time.")
+	flag.StringVar(&leaderElection.LeaseName, "leader-elect-lease-name", "arman-controller", "Name of the Lease used for leader election.")
+	flag.StringVar(&leaderElection.LeaseNamespace, "leader-elect-namespace", defaultLeaseNamespace(), "Namespace of the Lease used for leader election. Defaults to the namespace the controller runs in, or default out-of-cluster.")
+	flag.DurationVar(&leaderElection.LeaseDuration, "leader-elect-lease-duration", 15*time.Second, "How long followers wait after the last renewal before taking over the lease.")
+	flag.DurationVar(&leaderElection.RenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "How long the leader retries renewing the lease before giving up leadership. Must be less than the lease duration.")
+	flag.DurationVar(&leaderElection.RetryPeriod, "leader-elect-retry-period", 2*time.Second, "How long to wait between attempts to acquire or renew the lease.")
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/sheikh-arman/crd-controller/pkg/metrics"
+)
+
+// reconcileResult returns the result a reconcile that returned err is
+// counted under. Conflicts are expected when racing other writers and are
+// simply retried, so they are not counted as errors.
+func reconcileResult(err error) string {
+	switch {
+	case err == nil:
+		return metrics.ResultSuccess
+	case apierrors.IsConflict(err):
+		return metrics.ResultRequeue
+	default:
+		return metrics.ResultError
+	}
+}
+
+// recordChildRequest counts an API call on a child of an Arman.
+func recordChildRequest(kind, verb string, err error) {
+	result := metrics.ResultSuccess
+	if err != nil && !apierrors.IsNotFound(err) {
+		result = metrics.ResultError
+	}
+	metrics.ChildRequestsTotal.WithLabelValues(kind, verb, result).Inc()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	myfake "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/fake"
+	"github.com/sheikh-arman/crd-controller/pkg/metrics"
+)
+
+func TestReconcileMetrics(t *testing.T) {
+	tests := []struct {
+		name string
+		// updateErr is returned when the finalizer is added.
+		updateErr  error
+		wantResult string
+	}{
+		{"success", nil, metrics.ResultSuccess},
+		{"conflict", apierrors.NewConflict(schema.GroupResource{Group: "arman.com", Resource: "armans"}, "web", fmt.Errorf("the object has been modified")), metrics.ResultRequeue},
+		{"error", apierrors.NewInternalError(fmt.Errorf("etcd is down")), metrics.ResultError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.ReconcileTotal.Reset()
+			metrics.ReconcileDuration.Reset()
+			// The Arman has no finalizer yet, so a reconcile starts by
+			// adding it.
+			arman := newArman("web", armanUID)
+			arman.Spec = syncedArman().Spec
+			c := newTestController(t, arman)
+			armanClient := c.sampleclientset.(*myfake.Clientset)
+			if err := armanClient.Tracker().Add(arman); err != nil {
+				t.Fatal(err)
+			}
+			// The fake clientset cannot apply objects that do not exist yet.
+			kubeClient := c.kubeclientset.(*k8sfake.Clientset)
+			kubeClient.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &appsv1.Deployment{ObjectMeta: controlledBy("web", "deployment-uid")}, nil
+			})
+			kubeClient.PrependReactor("patch", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &corev1.Service{ObjectMeta: controlledBy("web", "service-uid")}, nil
+			})
+			if tt.updateErr != nil {
+				armanClient.PrependReactor("update", "armans", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.updateErr
+				})
+			}
+
+			c.workqueue.Add("default/web")
+			c.processNextWorkItem(context.Background())
+
+			want := fmt.Sprintf(`
+# HELP arman_controller_reconcile_total Total number of Arman reconciles by result.
+# TYPE arman_controller_reconcile_total counter
+arman_controller_reconcile_total{result=%q} 1
+`, tt.wantResult)
+			if err := testutil.CollectAndCompare(metrics.ReconcileTotal, strings.NewReader(want)); err != nil {
+				t.Error(err)
+			}
+
+			// The duration varies, so only the number of observations is
+			// compared.
+			registry := prometheus.NewPedanticRegistry()
+			registry.MustRegister(metrics.ReconcileDuration)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(families) != 1 || len(families[0].Metric) != 1 {
+				t.Fatalf("reconcile durations = %v, want one series", families)
+			}
+			series := families[0].Metric[0]
+			if label := series.Label[0]; label.GetName() != "result" || label.GetValue() != tt.wantResult {
+				t.Errorf("reconcile duration labeled %s=%s, want result=%s", label.GetName(), label.GetValue(), tt.wantResult)
+			}
+			if count := series.Histogram.GetSampleCount(); count != 1 {
+				t.Errorf("reconcile durations observed %d times, want 1", count)
+			}
+		})
+	}
+}
+
+func TestRecordChildRequest(t *testing.T) {
+	metrics.ChildRequestsTotal.Reset()
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	recordChildRequest("Deployment", "create", nil)
+	recordChildRequest("Deployment", "update", nil)
+	recordChildRequest("Deployment", "update", apierrors.NewConflict(deployments, "web", fmt.Errorf("conflict")))
+	// Deleting a child that is already gone is what was asked for.
+	recordChildRequest("Service", "delete", apierrors.NewNotFound(schema.GroupResource{Resource: "services"}, "web"))
+
+	want := `
+# HELP arman_controller_child_requests_total Total number of API calls creating, updating or deleting children of Armans by kind, verb and result.
+# TYPE arman_controller_child_requests_total counter
+arman_controller_child_requests_total{kind="Deployment",result="error",verb="update"} 1
+arman_controller_child_requests_total{kind="Deployment",result="success",verb="create"} 1
+arman_controller_child_requests_total{kind="Deployment",result="success",verb="update"} 1
+arman_controller_child_requests_total{kind="Service",result="success",verb="delete"} 1
+`
+	if err := testutil.CollectAndCompare(metrics.ChildRequestsTotal, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myclientset "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned"
+	"github.com/sheikh-arman/crd-controller/pkg/signals"
+)
+
+// MigrateOptions configures the storage version migration of Armans.
+type MigrateOptions struct {
+	// CRDName is the name of the CustomResourceDefinition of Armans.
+	CRDName string
+	// PageSize is the number of Armans listed at a time.
+	PageSize int64
+}
+
+// runMigrate runs the migrate subcommand with args, the arguments after its
+// name. The connection flags of the controller can be given before or after
+// the subcommand.
+func runMigrate(args []string) error {
+	opts := MigrateOptions{}
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	for _, name := range []string{"kubeconfig", "master", "kube-api-qps", "kube-api-burst"} {
+		f := flag.CommandLine.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	klog.InitFlags(fs)
+	fs.StringVar(&opts.CRDName, "crd-name", myv1beta1.Resource("armans").String(), "Name of the CustomResourceDefinition of Armans.")
+	fs.Int64Var(&opts.PageSize, "page-size", 500, "Number of Armans listed at a time.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s migrate [flags]\n\n", fs.Name())
+		fmt.Fprintln(fs.Output(), "Rewrites every Arman in the storage version of the CRD, then drops the other versions from its status.storedVersions.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	ctx := signals.SetupSignalHandler()
+
+	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
+	if err != nil {
+		return fmt.Errorf("building kubeconfig: %w", err)
+	}
+	cfg.QPS = float32(qps)
+	cfg.Burst = burst
+
+	armanClient, err := myclientset.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("building arman clientset: %w", err)
+	}
+	crdClient, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("building apiextensions clientset: %w", err)
+	}
+	return migrateArmans(ctx, armanClient, crdClient, opts)
+}
+
+// migrateArmans rewrites every Arman with an update that changes nothing, so
+// that the API server encodes it in the storage version of the CRD. Once all
+// of them are rewritten, the versions the CRD no longer stores are dropped
+// from its status.storedVersions, so that they can be removed from the CRD.
+func migrateArmans(ctx context.Context, armanClient myclientset.Interface, crdClient apiextensionsclientset.Interface, opts MigrateOptions) error {
+	crd, err := crdClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, opts.CRDName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	storageVersion := storageVersionOf(crd)
+	if storageVersion == "" {
+		return fmt.Errorf("CustomResourceDefinition %s has no storage version", crd.Name)
+	}
+	klog.Infof("Migrating armans to storage version %s, stored versions are %v", storageVersion, crd.Status.StoredVersions)
+
+	var migrated, failed int
+	listOptions := metav1.ListOptions{Limit: opts.PageSize}
+	for {
+		armans, err := armanClient.ArmanV1beta1().Armans(metav1.NamespaceAll).List(ctx, listOptions)
+		if err != nil {
+			return fmt.Errorf("listing armans: %w", err)
+		}
+		for i := range armans.Items {
+			arman := &armans.Items[i]
+			if err := migrateArman(ctx, armanClient, arman); err != nil {
+				klog.Errorf("Error migrating arman %s/%s: %s", arman.Namespace, arman.Name, err.Error())
+				failed++
+				continue
+			}
+			klog.V(4).Infof("Migrated arman %s/%s", arman.Namespace, arman.Name)
+			migrated++
+		}
+		klog.Infof("Migrated %d armans, %d failed", migrated, failed)
+
+		if armans.Continue == "" {
+			break
+		}
+		listOptions.Continue = armans.Continue
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d armans could not be migrated, stored versions are left as they are", failed)
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		crd, err := crdClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, opts.CRDName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		// The storage version may have changed while migrating, in which case
+		// the Armans are not all stored in the new one yet.
+		if storageVersionOf(crd) != storageVersion {
+			return fmt.Errorf("storage version of CustomResourceDefinition %s changed from %s while migrating", crd.Name, storageVersion)
+		}
+		if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+			return nil
+		}
+		crd.Status.StoredVersions = []string{storageVersion}
+		_, err = crdClient.ApiextensionsV1().CustomResourceDefinitions().UpdateStatus(ctx, crd, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("updating stored versions: %w", err)
+	}
+	klog.Infof("Migrated %d armans, stored versions are [%s]", migrated, storageVersion)
+	return nil
+}
+
+// migrateArman rewrites arman unchanged. The update is guarded by its
+// resourceVersion, so that a concurrent change is never overwritten; on a
+// conflict the Arman is read again, and one that was deleted meanwhile needs
+// no migration.
+func migrateArman(ctx context.Context, armanClient myclientset.Interface, arman *myv1beta1.Arman) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		_, err := armanClient.ArmanV1beta1().Armans(arman.Namespace).Update(ctx, arman, metav1.UpdateOptions{})
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		latest, getErr := armanClient.ArmanV1beta1().Armans(arman.Namespace).Get(ctx, arman.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		arman = latest
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// storageVersionOf returns the name of the version crd is stored in.
+func storageVersionOf(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	return ""
+}
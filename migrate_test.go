@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myfake "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/fake"
+)
+
+const crdName = "armans.arman.com"
+
+func newCRD(storedVersions ...string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: crdName},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Served: true},
+				{Name: "v1beta1", Served: true, Storage: true},
+			},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+	}
+}
+
+func newStoredArman(namespace, name string) *myv1beta1.Arman {
+	return &myv1beta1.Arman{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func TestMigrateArmans(t *testing.T) {
+	tests := []struct {
+		name string
+		// updateErrs are returned, in order, by the updates of the Arman
+		// named "web".
+		updateErrs         []error
+		wantErr            bool
+		wantUpdates        int
+		wantStoredVersions []string
+	}{
+		{
+			name:               "all armans migrated",
+			wantUpdates:        2,
+			wantStoredVersions: []string{"v1beta1"},
+		},
+		{
+			name:               "conflict is retried",
+			updateErrs:         []error{apierrors.NewConflict(myv1beta1.Resource("armans"), "web", errors.New("changed"))},
+			wantUpdates:        3,
+			wantStoredVersions: []string{"v1beta1"},
+		},
+		{
+			name:               "deleted arman is skipped",
+			updateErrs:         []error{apierrors.NewNotFound(myv1beta1.Resource("armans"), "web")},
+			wantUpdates:        2,
+			wantStoredVersions: []string{"v1beta1"},
+		},
+		{
+			name:               "failure keeps stored versions",
+			updateErrs:         []error{apierrors.NewForbidden(myv1beta1.Resource("armans"), "web", errors.New("denied"))},
+			wantErr:            true,
+			wantUpdates:        2,
+			wantStoredVersions: []string{"v1alpha1", "v1beta1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			armanClient := myfake.NewSimpleClientset(newStoredArman("default", "web"), newStoredArman("team-a", "api"))
+			crdClient := apiextensionsfake.NewSimpleClientset(newCRD("v1alpha1", "v1beta1"))
+
+			updates := 0
+			updateErrs := tt.updateErrs
+			armanClient.PrependReactor("update", "armans", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				arman := action.(k8stesting.UpdateAction).GetObject().(*myv1beta1.Arman)
+				if arman.Name != "web" || len(updateErrs) == 0 {
+					return false, nil, nil
+				}
+				err := updateErrs[0]
+				updateErrs = updateErrs[1:]
+				return true, nil, err
+			})
+
+			err := migrateArmans(context.Background(), armanClient, crdClient, MigrateOptions{CRDName: crdName, PageSize: 1})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrateArmans() error = %v, want error %v", err, tt.wantErr)
+			}
+			if updates != tt.wantUpdates {
+				t.Errorf("got %d updates, want %d", updates, tt.wantUpdates)
+			}
+			crd, err := crdClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.Background(), crdName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(crd.Status.StoredVersions, tt.wantStoredVersions) {
+				t.Errorf("got stored versions %v, want %v", crd.Status.StoredVersions, tt.wantStoredVersions)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// SpecAnnotation holds the fields of the v1beta1 spec of an Arman read as
+// v1alpha1 that v1alpha1 cannot represent, so that converting it back to
+// v1beta1 does not lose them. SecretFiles are never copied into it, as
+// annotations are readable by anyone who can read the Arman.
+const SpecAnnotation = "arman.com/v1beta1-spec"
+
+// IncompleteAnnotation is set on an Arman read as v1alpha1 whose v1beta1
+// spec SpecAnnotation does not hold in full: secretFiles if it has any, or
+// the whole spec if it does not fit into the annotations. Its value names
+// what is missing. Such an Arman cannot be converted back to v1beta1, so it
+// cannot be written through v1alpha1.
+const IncompleteAnnotation = "arman.com/v1beta1-spec-incomplete"
+
+func addConversionFuncs(scheme *runtime.Scheme) error {
+	if err := scheme.AddConversionFunc((*Arman)(nil), (*v1beta1.Arman)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Arman_To_v1beta1_Arman(a.(*Arman), b.(*v1beta1.Arman), scope)
+	}); err != nil {
+		return err
+	}
+	if err := scheme.AddConversionFunc((*v1beta1.Arman)(nil), (*Arman)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Arman_To_v1alpha1_Arman(a.(*v1beta1.Arman), b.(*Arman), scope)
+	}); err != nil {
+		return err
+	}
+	if err := scheme.AddConversionFunc((*ArmanList)(nil), (*v1beta1.ArmanList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ArmanList_To_v1beta1_ArmanList(a.(*ArmanList), b.(*v1beta1.ArmanList), scope)
+	}); err != nil {
+		return err
+	}
+	return scheme.AddConversionFunc((*v1beta1.ArmanList)(nil), (*ArmanList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ArmanList_To_v1alpha1_ArmanList(a.(*v1beta1.ArmanList), b.(*ArmanList), scope)
+	})
+}
+
+// Convert_v1alpha1_Arman_To_v1beta1_Arman converts an Arman to v1beta1. The
+// fields kept in SpecAnnotation are restored and the annotation is dropped.
+// An Arman with IncompleteAnnotation is not converted, as its spec would
+// lose the fields that were not kept.
+func Convert_v1alpha1_Arman_To_v1beta1_Arman(in *Arman, out *v1beta1.Arman, _ conversion.Scope) error {
+	if missing, ok := in.Annotations[IncompleteAnnotation]; ok {
+		return fmt.Errorf("arman %s/%s cannot be converted to v1beta1: its %s are not kept in v1alpha1, write it through v1beta1 instead", in.Namespace, in.Name, missing)
+	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	out.Spec = v1beta1.ArmanSpec{}
+	if data, ok := out.Annotations[SpecAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &out.Spec); err != nil {
+			return err
+		}
+		delete(out.Annotations, SpecAnnotation)
+		if len(out.Annotations) == 0 {
+			out.Annotations = nil
+		}
+	}
+	convertSpecToV1beta1(&in.Spec, &out.Spec)
+	convertStatusToV1beta1(&in.Status, &out.Status)
+	return nil
+}
+
+// Convert_v1beta1_Arman_To_v1alpha1_Arman converts an Arman to v1alpha1.
+// If its spec has fields v1alpha1 cannot represent, they are kept in
+// SpecAnnotation, and IncompleteAnnotation names those that could not be.
+func Convert_v1beta1_Arman_To_v1alpha1_Arman(in *v1beta1.Arman, out *Arman, _ conversion.Scope) error {
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	delete(out.Annotations, SpecAnnotation)
+	delete(out.Annotations, IncompleteAnnotation)
+
+	out.Spec = ArmanSpec{}
+	convertSpecToV1alpha1(&in.Spec, &out.Spec)
+
+	if spec := unrepresentableFields(&in.Spec); !equality.Semantic.DeepEqual(spec, &v1beta1.ArmanSpec{}) {
+		data, err := json.Marshal(spec)
+		if err != nil {
+			return err
+		}
+		setAnnotation(&out.ObjectMeta, SpecAnnotation, string(data))
+	}
+	if len(in.Spec.SecretFiles) > 0 {
+		setAnnotation(&out.ObjectMeta, IncompleteAnnotation, "secretFiles")
+	}
+	if _, ok := out.Annotations[SpecAnnotation]; ok && apivalidation.ValidateAnnotationsSize(out.Annotations) != nil {
+		delete(out.Annotations, SpecAnnotation)
+		setAnnotation(&out.ObjectMeta, IncompleteAnnotation, "spec fields")
+	}
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+
+	convertStatusToV1alpha1(&in.Status, &out.Status)
+	return nil
+}
+
+// unrepresentableFields returns spec without the fields v1alpha1 represents
+// and without SecretFiles. The single port fields are kept, as converting
+// back to v1beta1 compares them to the v1alpha1 ones to tell whether the
+// first port changed, unless the spec has no ports for them to describe.
+func unrepresentableFields(spec *v1beta1.ArmanSpec) *v1beta1.ArmanSpec {
+	rest := spec.DeepCopy()
+	rest.Workload.Name = ""
+	rest.Workload.Image = ""
+	rest.Workload.Replicas = nil
+	rest.Service.Name = ""
+	rest.Service.Type = ""
+	rest.DeletionPolicy = ""
+	rest.RenamePolicy = ""
+	rest.SecretFiles = nil
+	if len(rest.Service.Ports) == 0 {
+		rest.Service.Port = 0
+		rest.Service.TargetPort = 0
+		rest.Service.NodePort = 0
+	}
+	return rest
+}
+
+func setAnnotation(meta *metav1.ObjectMeta, key, value string) {
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[key] = value
+}
+
+// Convert_v1alpha1_ArmanList_To_v1beta1_ArmanList converts an ArmanList to
+// v1beta1.
+func Convert_v1alpha1_ArmanList_To_v1beta1_ArmanList(in *ArmanList, out *v1beta1.ArmanList, s conversion.Scope) error {
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	out.Items = nil
+	if in.Items != nil {
+		out.Items = make([]v1beta1.Arman, len(in.Items))
+		for i := range in.Items {
+			if err := Convert_v1alpha1_Arman_To_v1beta1_Arman(&in.Items[i], &out.Items[i], s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Convert_v1beta1_ArmanList_To_v1alpha1_ArmanList converts an ArmanList to
+// v1alpha1.
+func Convert_v1beta1_ArmanList_To_v1alpha1_ArmanList(in *v1beta1.ArmanList, out *ArmanList, s conversion.Scope) error {
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	out.Items = nil
+	if in.Items != nil {
+		out.Items = make([]Arman, len(in.Items))
+		for i := range in.Items {
+			if err := Convert_v1beta1_Arman_To_v1alpha1_Arman(&in.Items[i], &out.Items[i], s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// convertSpecToV1beta1 sets the fields of out that v1alpha1 can represent,
+// leaving the others alone.
+func convertSpecToV1beta1(in *ArmanSpec, out *v1beta1.ArmanSpec) {
+	// The single port of v1alpha1 is the first of the ports of v1beta1, so
+	// a port changed through v1alpha1 changes that one too.
+	if len(out.Service.Ports) > 0 {
+		first := &out.Service.Ports[0]
+		if in.ServicePort != out.Service.Port {
+			first.Port = in.ServicePort
+		}
+		if in.ServiceTargetPort != out.Service.TargetPort {
+			first.TargetPort = intstr.FromInt(int(in.ServiceTargetPort))
+		}
+		if in.NodePort != out.Service.NodePort {
+			first.NodePort = in.NodePort
+		}
+	}
+
+	out.Workload.Name = in.DeploymentName
+	out.Workload.Image = in.DeploymentImage
+	out.Workload.Replicas = nil
+	if in.Replicas != nil {
+		replicas := *in.Replicas
+		out.Workload.Replicas = &replicas
+	}
+	out.Service.Name = in.ServiceName
+	out.Service.Type = in.ServiceType
+	out.Service.Port = in.ServicePort
+	out.Service.TargetPort = in.ServiceTargetPort
+	out.Service.NodePort = in.NodePort
+	out.DeletionPolicy = v1beta1.DeletionPolicy(in.DeletionPolicy)
+	out.RenamePolicy = v1beta1.RenamePolicy(in.RenamePolicy)
+}
+
+func convertSpecToV1alpha1(in *v1beta1.ArmanSpec, out *ArmanSpec) {
+	out.DeploymentName = in.Workload.Name
+	out.DeploymentImage = in.Workload.Image
+	out.Replicas = nil
+	if in.Workload.Replicas != nil {
+		replicas := *in.Workload.Replicas
+		out.Replicas = &replicas
+	}
+	out.ServiceName = in.Service.Name
+	out.ServiceType = in.Service.Type
+	out.ServicePort = in.Service.Port
+	out.ServiceTargetPort = in.Service.TargetPort
+	out.NodePort = in.Service.NodePort
+	out.DeletionPolicy = DeletionPolicy(in.DeletionPolicy)
+	out.RenamePolicy = RenamePolicy(in.RenamePolicy)
+}
+
+func convertStatusToV1beta1(in *ArmanStatus, out *v1beta1.ArmanStatus) {
+	*out = v1beta1.ArmanStatus{
+		ObservedGeneration: in.ObservedGeneration,
+		DeploymentName:     in.DeploymentName,
+		ServiceName:        in.ServiceName,
+		Endpoint:           in.Endpoint,
+		Replicas:           in.Replicas,
+		Selector:           in.Selector,
+		AvailableReplicas:  in.AvailableReplicas,
+		ReadyReplicas:      in.ReadyReplicas,
+		UpdatedReplicas:    in.UpdatedReplicas,
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+func convertStatusToV1alpha1(in *v1beta1.ArmanStatus, out *ArmanStatus) {
+	*out = ArmanStatus{
+		ObservedGeneration: in.ObservedGeneration,
+		DeploymentName:     in.DeploymentName,
+		ServiceName:        in.ServiceName,
+		Endpoint:           in.Endpoint,
+		Replicas:           in.Replicas,
+		Selector:           in.Selector,
+		AvailableReplicas:  in.AvailableReplicas,
+		ReadyReplicas:      in.ReadyReplicas,
+		UpdatedReplicas:    in.UpdatedReplicas,
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+const fuzzIterations = 1000
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	return fuzz.New().NilChance(0.2).NumElements(0, 3).RandSource(rand.NewSource(seed)).Funcs(
+		// TypeMeta is set by the scheme, not by the conversion.
+		func(tm *metav1.TypeMeta, c fuzz.Continue) {},
+		// The annotations belong to the conversion, no user sets them.
+		func(m *metav1.ObjectMeta, c fuzz.Continue) {
+			c.FuzzNoCustom(m)
+			delete(m.Annotations, SpecAnnotation)
+			delete(m.Annotations, IncompleteAnnotation)
+		},
+		// Managed fields are JSON, which random bytes are not. They show up
+		// in the templates of ephemeral volumes.
+		func(f *metav1.FieldsV1, c fuzz.Continue) {
+			f.Raw = []byte("{}")
+		},
+	)
+}
+
+func TestRoundTripV1alpha1(t *testing.T) {
+	scheme := newScheme(t)
+	f := newFuzzer(1)
+	for i := 0; i < fuzzIterations; i++ {
+		in := &Arman{}
+		f.Fuzz(in)
+
+		hub := &v1beta1.Arman{}
+		if err := scheme.Convert(in, hub, nil); err != nil {
+			t.Fatalf("converting to v1beta1: %v", err)
+		}
+		out := &Arman{}
+		if err := scheme.Convert(hub, out, nil); err != nil {
+			t.Fatalf("converting to v1alpha1: %v", err)
+		}
+		if !equality.Semantic.DeepEqual(in, out) {
+			t.Fatalf("round trip through v1beta1 changed the Arman:\n%s", diff.ObjectReflectDiff(in, out))
+		}
+	}
+}
+
+func TestRoundTripV1beta1(t *testing.T) {
+	scheme := newScheme(t)
+	f := newFuzzer(2)
+	for i := 0; i < fuzzIterations; i++ {
+		in := &v1beta1.Arman{}
+		f.Fuzz(in)
+		// Secret files are not kept in v1alpha1, see TestSecretFilesNotKept.
+		in.Spec.SecretFiles = nil
+
+		spoke := &Arman{}
+		if err := scheme.Convert(in, spoke, nil); err != nil {
+			t.Fatalf("converting to v1alpha1: %v", err)
+		}
+		// Only the fields v1alpha1 cannot represent are kept.
+		if data, ok := spoke.Annotations[SpecAnnotation]; ok {
+			kept := &v1beta1.ArmanSpec{}
+			if err := json.Unmarshal([]byte(data), kept); err != nil {
+				t.Fatal(err)
+			}
+			if rest := unrepresentableFields(kept); !equality.Semantic.DeepEqual(rest, kept) {
+				t.Fatalf("%s keeps fields v1alpha1 represents:\n%s", SpecAnnotation, diff.ObjectReflectDiff(rest, kept))
+			}
+		}
+		out := &v1beta1.Arman{}
+		if err := scheme.Convert(spoke, out, nil); err != nil {
+			t.Fatalf("converting to v1beta1: %v", err)
+		}
+		if !equality.Semantic.DeepEqual(in, out) {
+			t.Fatalf("round trip through v1alpha1 changed the Arman:\n%s", diff.ObjectReflectDiff(in, out))
+		}
+	}
+}
+
+func TestRoundTripV1beta1List(t *testing.T) {
+	scheme := newScheme(t)
+	f := newFuzzer(3)
+	for i := 0; i < fuzzIterations/10; i++ {
+		in := &v1beta1.ArmanList{}
+		f.Fuzz(in)
+		for i := range in.Items {
+			in.Items[i].Spec.SecretFiles = nil
+		}
+
+		spoke := &ArmanList{}
+		if err := scheme.Convert(in, spoke, nil); err != nil {
+			t.Fatalf("converting to v1alpha1: %v", err)
+		}
+		out := &v1beta1.ArmanList{}
+		if err := scheme.Convert(spoke, out, nil); err != nil {
+			t.Fatalf("converting to v1beta1: %v", err)
+		}
+		if !equality.Semantic.DeepEqual(in, out) {
+			t.Fatalf("round trip through v1alpha1 changed the ArmanList:\n%s", diff.ObjectReflectDiff(in, out))
+		}
+	}
+}
+
+// TestRoundTripV1beta1LargeSpec converts Armans whose spec may not fit into
+// the annotations of the v1alpha1 Arman.
+func TestRoundTripV1beta1LargeSpec(t *testing.T) {
+	scheme := newScheme(t)
+	f := newFuzzer(4).Funcs(
+		func(spec *v1beta1.ArmanSpec, c fuzz.Continue) {
+			c.FuzzNoCustom(spec)
+			spec.SecretFiles = nil
+			spec.ConfigFiles = map[string]string{}
+			for i := c.Intn(4); i > 0; i-- {
+				spec.ConfigFiles[c.RandString()] = strings.Repeat(c.RandString(), c.Intn(128<<10))
+			}
+		},
+	)
+	var kept, dropped int
+	for i := 0; i < fuzzIterations/20; i++ {
+		in := &v1beta1.Arman{}
+		f.Fuzz(in)
+
+		spoke := &Arman{}
+		if err := scheme.Convert(in, spoke, nil); err != nil {
+			t.Fatalf("converting to v1alpha1: %v", err)
+		}
+		if err := apivalidation.ValidateAnnotationsSize(spoke.Annotations); err != nil {
+			t.Fatalf("v1alpha1 Arman has too large annotations: %v", err)
+		}
+
+		out := &v1beta1.Arman{}
+		err := scheme.Convert(spoke, out, nil)
+		if _, incomplete := spoke.Annotations[IncompleteAnnotation]; incomplete {
+			dropped++
+			if _, ok := spoke.Annotations[SpecAnnotation]; ok {
+				t.Fatalf("%s set although %s is", SpecAnnotation, IncompleteAnnotation)
+			}
+			if err == nil {
+				t.Fatalf("converting an incomplete Arman to v1beta1 succeeded")
+			}
+			continue
+		}
+		kept++
+		if err != nil {
+			t.Fatalf("converting to v1beta1: %v", err)
+		}
+		if !equality.Semantic.DeepEqual(in, out) {
+			t.Fatalf("round trip through v1alpha1 changed the Arman:\n%s", diff.ObjectReflectDiff(in, out))
+		}
+	}
+	if kept == 0 || dropped == 0 {
+		t.Errorf("%d specs kept and %d dropped, want some of both", kept, dropped)
+	}
+}
+
+func TestSecretFilesNotKept(t *testing.T) {
+	scheme := newScheme(t)
+	in := &v1beta1.Arman{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: metav1.NamespaceDefault},
+		Spec: v1beta1.ArmanSpec{
+			Workload:    v1beta1.WorkloadSpec{Name: "web", Image: "web:v1"},
+			ConfigFiles: map[string]string{"app.yaml": "debug: true"},
+			SecretFiles: map[string]string{"token": "s3cr3t"},
+		},
+	}
+
+	spoke := &Arman{}
+	if err := scheme.Convert(in, spoke, nil); err != nil {
+		t.Fatalf("converting to v1alpha1: %v", err)
+	}
+	for key, value := range spoke.Annotations {
+		if strings.Contains(value, "s3cr3t") || strings.Contains(value, "secretFiles\"") {
+			t.Errorf("annotation %s = %s holds the secret files", key, value)
+		}
+	}
+	if got := spoke.Annotations[IncompleteAnnotation]; got != "secretFiles" {
+		t.Errorf("%s = %q, want secretFiles", IncompleteAnnotation, got)
+	}
+	if got, want := spoke.Annotations[SpecAnnotation], `{"workload":{"image":"","resources":{}},"service":{},"configFiles":{"app.yaml":"debug: true"}}`; got != want {
+		t.Errorf("%s = %s, want %s", SpecAnnotation, got, want)
+	}
+
+	// Writing the Arman through v1alpha1 would drop the secret files.
+	if err := scheme.Convert(spoke, &v1beta1.Arman{}, nil); err == nil {
+		t.Errorf("converting to v1beta1 succeeded, want an error")
+	}
+}
+
+func TestPortChangedThroughV1alpha1(t *testing.T) {
+	scheme := newScheme(t)
+	in := &v1beta1.Arman{
+		Spec: v1beta1.ArmanSpec{
+			Service: v1beta1.ServiceSpec{
+				Port:       80,
+				TargetPort: 8080,
+				Ports: []v1beta1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP},
+					{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090), Protocol: corev1.ProtocolTCP},
+				},
+			},
+		},
+	}
+
+	spoke := &Arman{}
+	if err := scheme.Convert(in, spoke, nil); err != nil {
+		t.Fatalf("converting to v1alpha1: %v", err)
+	}
+	spoke.Spec.ServiceTargetPort = 8443
+	out := &v1beta1.Arman{}
+	if err := scheme.Convert(spoke, out, nil); err != nil {
+		t.Fatalf("converting to v1beta1: %v", err)
+	}
+
+	want := in.DeepCopy()
+	want.Spec.Service.TargetPort = 8443
+	want.Spec.Service.Ports[0].TargetPort = intstr.FromInt(8443)
+	if !equality.Semantic.DeepEqual(want, out) {
+		t.Errorf("unexpected v1beta1 Arman:\n%s", diff.ObjectReflectDiff(want, out))
+	}
+}
@@ -0,0 +1,53 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Labels put on every Arman by defaulting, from the recommended labels of
+// https://kubernetes.io/docs/concepts/overview/working-with-objects/common-labels/.
+const (
+	LabelName     = "app.kubernetes.io/name"
+	LabelInstance = "app.kubernetes.io/instance"
+)
+
+func addDefaultingFuncs(scheme *runtime.Scheme) error {
+	return RegisterDefaults(scheme)
+}
+
+// SetDefaults_Arman defaults the fields of an Arman derived from its
+// metadata. The names are left empty if the Arman has no name yet.
+func SetDefaults_Arman(obj *Arman) {
+	if obj.Spec.DeploymentName == "" {
+		obj.Spec.DeploymentName = obj.Name
+	}
+	if obj.Spec.ServiceName == "" {
+		obj.Spec.ServiceName = obj.Name
+	}
+
+	if obj.Labels == nil {
+		obj.Labels = map[string]string{}
+	}
+	if _, ok := obj.Labels[LabelName]; !ok {
+		obj.Labels[LabelName] = "arman"
+	}
+	if _, ok := obj.Labels[LabelInstance]; !ok && obj.Name != "" {
+		obj.Labels[LabelInstance] = obj.Name
+	}
+}
+
+// SetDefaults_ArmanSpec defaults the fields of an ArmanSpec.
+func SetDefaults_ArmanSpec(obj *ArmanSpec) {
+	if obj.ServiceType == "" {
+		obj.ServiceType = "ClusterIP"
+	}
+	if obj.ServiceTargetPort == 0 {
+		obj.ServiceTargetPort = obj.ServicePort
+	}
+	if obj.DeletionPolicy == "" {
+		obj.DeletionPolicy = DeletionPolicyDelete
+	}
+	if obj.RenamePolicy == "" {
+		obj.RenamePolicy = RenamePolicyForbid
+	}
+}
@@ -25,7 +25,7 @@ func Resource(resource string) schema.GroupResource {
 }
 
 func init() {
-	SchemeBuilder.Register(addKnownTypes)
+	SchemeBuilder.Register(addKnownTypes, addDefaultingFuncs, addConversionFuncs)
 }
 
 func addKnownTypes(scheme *runtime.Scheme) error {
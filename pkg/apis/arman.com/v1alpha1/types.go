@@ -6,6 +6,15 @@ import (
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=armans,singular=arman,shortName=am,categories=arman
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=".spec.deploymentImage"
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=".spec.replicas"
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=".status.availableReplicas"
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:validation:XValidation:rule="(has(oldSelf.spec.renamePolicy) && oldSelf.spec.renamePolicy == 'Allow') || ((has(self.spec.deploymentName) ? self.spec.deploymentName : self.metadata.name) == (has(oldSelf.spec.deploymentName) ? oldSelf.spec.deploymentName : oldSelf.metadata.name) && (has(self.spec.serviceName) ? self.spec.serviceName : self.metadata.name) == (has(oldSelf.spec.serviceName) ? oldSelf.spec.serviceName : oldSelf.metadata.name))",message="spec.deploymentName and spec.serviceName are immutable unless spec.renamePolicy is Allow"
 type Arman struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -15,19 +24,150 @@ type Arman struct {
 }
 
 type ArmanStatus struct {
+	// ObservedGeneration is the generation of the Arman the status was
+	// computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe the current state of the Arman. The known types
+	// are Ready, Progressing, Degraded and ResourceConflict.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// DeploymentName is the name of the Deployment managed for the Arman.
+	// +optional
+	DeploymentName string `json:"deploymentName,omitempty"`
+	// ServiceName is the name of the Service managed for the Arman.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+	// Endpoint is the address the Service can be reached at.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Replicas is the number of pods of the Deployment, for the scale
+	// subresource.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the pods of the Deployment, in
+	// string form, for the scale subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
 	AvailableReplicas int32 `json:"availableReplicas"`
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
 }
 
+// These are the condition types of an Arman.
+const (
+	// ConditionReady is true when the Deployment is fully rolled out and
+	// available and the Service exists.
+	ConditionReady = "Ready"
+	// ConditionProgressing is true while the Deployment is rolling out.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is true when the Arman could not be synced or its
+	// Deployment failed to make progress.
+	ConditionDegraded = "Degraded"
+	// ConditionResourceConflict is true when a child resource is owned by
+	// someone else, either as a whole or through conflicting fields.
+	ConditionResourceConflict = "ResourceConflict"
+)
+
+// +kubebuilder:validation:XValidation:rule="!has(self.nodePort) || (has(self.serviceType) && self.serviceType == 'NodePort')",message="nodePort may only be set when serviceType is NodePort"
 type ArmanSpec struct {
-	DeploymentName    string `json:"deploymentName"`
-	DeploymentImage   string `json:"deploymentImage"`
-	Replicas          *int32 `json:"replicas"`
-	ServiceName       string `json:"serviceName"`
-	ServicePort       int32  `json:"servicePort"`
-	ServiceType       string `json:"serviceType"`
-	ServiceTargetPort int32  `json:"serviceTargetPort"`
+	// DeploymentName is the name of the Deployment. Defaults to the name of
+	// the Arman.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	DeploymentName string `json:"deploymentName,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+	DeploymentImage string `json:"deploymentImage"`
+	// Replicas is the number of pods of the Deployment. While it is set, the
+	// controller owns the replicas of the Deployment, so a
+	// HorizontalPodAutoscaler must target the Arman, whose scale subresource
+	// sets this field, rather than the Deployment. While it is unset, the
+	// replicas of the Deployment are left to others, like an HPA targeting
+	// the Deployment, and default to 1; the scale subresource of the Arman
+	// only works with the field set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+	// ServiceName is the name of the Service. Defaults to the name of the
+	// Arman.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	ServiceName string `json:"serviceName,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ServicePort int32 `json:"servicePort"`
+	// ServiceType is the type of the Service. Defaults to ClusterIP.
+	// +optional
+	// +kubebuilder:default=ClusterIP
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	ServiceType string `json:"serviceType,omitempty"`
+	// ServiceTargetPort is the port the container listens on. Defaults to
+	// ServicePort.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ServiceTargetPort int32 `json:"serviceTargetPort,omitempty"`
+	// NodePort is the port the Service is exposed on on every node. Only
+	// allowed with ServiceType NodePort; allocated by the API server if
+	// unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// DeletionPolicy decides what happens to the Deployment and the Service
+	// when the Arman is deleted. Defaults to Delete.
+	// +optional
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// RenamePolicy decides whether DeploymentName and ServiceName may be
+	// changed once the Arman exists. Defaults to Forbid.
+	// +optional
+	// +kubebuilder:default=Forbid
+	RenamePolicy RenamePolicy `json:"renamePolicy,omitempty"`
 }
 
+// DeletionPolicy decides what happens to the children of an Arman when it
+// is deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan;RetainService
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Deployment and the Service.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan removes the owner references from the Deployment
+	// and the Service and leaves them running.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyRetainService deletes the Deployment but orphans the
+	// Service, so that its DNS name stays stable.
+	DeletionPolicyRetainService DeletionPolicy = "RetainService"
+)
+
+// RenamePolicy decides whether the children of an Arman may be renamed.
+// +kubebuilder:validation:Enum=Forbid;Allow
+type RenamePolicy string
+
+const (
+	// RenamePolicyForbid keeps DeploymentName and ServiceName immutable.
+	RenamePolicyForbid RenamePolicy = "Forbid"
+	// RenamePolicyAllow lets DeploymentName and ServiceName be changed. The
+	// children under the old names are then deleted.
+	RenamePolicyAllow RenamePolicy = "Allow"
+)
+
+// CleanupFinalizer is put on every Arman by the controller, so that its
+// children are cleaned up according to its DeletionPolicy before it is
+// removed.
+const CleanupFinalizer = "arman.com/cleanup"
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 type ArmanList struct {
 	metav1.TypeMeta `json:",inline"`
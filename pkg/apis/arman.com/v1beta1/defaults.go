@@ -0,0 +1,82 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Labels put on every Arman by defaulting, from the recommended labels of
+// https://kubernetes.io/docs/concepts/overview/working-with-objects/common-labels/.
+const (
+	LabelName     = "app.kubernetes.io/name"
+	LabelInstance = "app.kubernetes.io/instance"
+)
+
+func addDefaultingFuncs(scheme *runtime.Scheme) error {
+	return RegisterDefaults(scheme)
+}
+
+// SetDefaults_Arman defaults the fields of an Arman derived from its
+// metadata. The names are left empty if the Arman has no name yet.
+func SetDefaults_Arman(obj *Arman) {
+	if obj.Spec.Workload.Name == "" {
+		obj.Spec.Workload.Name = obj.Name
+	}
+	if obj.Spec.Service.Name == "" {
+		obj.Spec.Service.Name = obj.Name
+	}
+
+	if obj.Labels == nil {
+		obj.Labels = map[string]string{}
+	}
+	if _, ok := obj.Labels[LabelName]; !ok {
+		obj.Labels[LabelName] = "arman"
+	}
+	if _, ok := obj.Labels[LabelInstance]; !ok && obj.Name != "" {
+		obj.Labels[LabelInstance] = obj.Name
+	}
+}
+
+// SetDefaults_ArmanSpec defaults the policies and the config revision
+// history limit of an ArmanSpec.
+func SetDefaults_ArmanSpec(obj *ArmanSpec) {
+	if obj.DeletionPolicy == "" {
+		obj.DeletionPolicy = DeletionPolicyDelete
+	}
+	if obj.RenamePolicy == "" {
+		obj.RenamePolicy = RenamePolicyForbid
+	}
+	if obj.ConfigRevisionHistoryLimit == nil {
+		limit := int32(10)
+		obj.ConfigRevisionHistoryLimit = &limit
+	}
+}
+
+// SetDefaults_ServiceSpec defaults the fields of a ServiceSpec. A Service
+// described by the single port fields gets that port as its only one.
+func SetDefaults_ServiceSpec(obj *ServiceSpec) {
+	if obj.Type == "" {
+		obj.Type = "ClusterIP"
+	}
+	if obj.TargetPort == 0 {
+		obj.TargetPort = obj.Port
+	}
+	if len(obj.Ports) == 0 && obj.Port != 0 {
+		obj.Ports = []ServicePort{{
+			Port:       obj.Port,
+			TargetPort: intstr.FromInt(int(obj.TargetPort)),
+			NodePort:   obj.NodePort,
+		}}
+	}
+}
+
+// SetDefaults_ServicePort defaults the fields of a ServicePort.
+func SetDefaults_ServicePort(obj *ServicePort) {
+	if obj.TargetPort.Type == intstr.Int && obj.TargetPort.IntVal == 0 {
+		obj.TargetPort = intstr.FromInt(int(obj.Port))
+	}
+	if obj.Protocol == "" {
+		obj.Protocol = corev1.ProtocolTCP
+	}
+}
@@ -0,0 +1,372 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=armans,singular=arman,shortName=am,categories=arman
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.workload.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=".spec.workload.image"
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=".spec.workload.replicas"
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=".status.availableReplicas"
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:validation:XValidation:rule="(has(oldSelf.spec.renamePolicy) && oldSelf.spec.renamePolicy == 'Allow') || ((has(self.spec.workload.name) ? self.spec.workload.name : self.metadata.name) == (has(oldSelf.spec.workload.name) ? oldSelf.spec.workload.name : oldSelf.metadata.name) && (has(self.spec.service.name) ? self.spec.service.name : self.metadata.name) == (has(oldSelf.spec.service.name) ? oldSelf.spec.service.name : oldSelf.metadata.name))",message="spec.workload.name and spec.service.name are immutable unless spec.renamePolicy is Allow"
+type Arman struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArmanSpec   `json:"spec,omitempty"`
+	Status ArmanStatus `json:"status,omitempty"`
+}
+
+// ArmanSpec describes the Deployment and the Service of an Arman.
+type ArmanSpec struct {
+	// Workload describes the Deployment.
+	Workload WorkloadSpec `json:"workload"`
+	// Service describes the Service in front of the pods of the Deployment.
+	Service ServiceSpec `json:"service"`
+
+	// DeletionPolicy decides what happens to the Deployment and the Service
+	// when the Arman is deleted. Defaults to Delete.
+	// +optional
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// RenamePolicy decides whether Workload.Name and Service.Name may be
+	// changed once the Arman exists. Defaults to Forbid.
+	// +optional
+	// +kubebuilder:default=Forbid
+	RenamePolicy RenamePolicy `json:"renamePolicy,omitempty"`
+
+	// ConfigFiles are small config files, by file name, written into a
+	// ConfigMap owned by the Arman and mounted read-only at
+	// ConfigFilesMountPath. The ConfigMap is named after the Deployment and a
+	// hash of the files, so that changing them rolls the pods.
+	// +optional
+	ConfigFiles map[string]string `json:"configFiles,omitempty"`
+	// SecretFiles are like ConfigFiles, but written into a Secret mounted
+	// read-only at SecretFilesMountPath.
+	//
+	// They are stored in plaintext in the Arman: anyone who can get, list or
+	// watch Armans can read them, and they end up wherever the Arman is
+	// copied to, like backups and audit logs at the RequestResponse level.
+	// Only the Secret written from them is protected like a Secret. Keep real
+	// credentials in a Secret of their own and reference it through
+	// configMounts or envFrom instead. Armans with SecretFiles cannot be
+	// written through v1alpha1, which does not keep them.
+	// +optional
+	SecretFiles map[string]string `json:"secretFiles,omitempty"`
+	// ConfigRevisionHistoryLimit is the number of superseded ConfigMaps and
+	// Secrets holding ConfigFiles and SecretFiles kept around, so that
+	// rolling the Deployment back finds its config. Defaults to 10, like the
+	// revision history of a Deployment.
+	// +optional
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	ConfigRevisionHistoryLimit *int32 `json:"configRevisionHistoryLimit,omitempty"`
+}
+
+// WorkloadSpec describes the Deployment of an Arman.
+type WorkloadSpec struct {
+	// Name is the name of the Deployment. Defaults to the name of the Arman.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name,omitempty"`
+	// Image is the image the container runs.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+	// Replicas is the number of pods of the Deployment. While it is set, the
+	// controller owns the replicas of the Deployment, so a
+	// HorizontalPodAutoscaler must target the Arman, whose scale subresource
+	// sets this field, rather than the Deployment. While it is unset, the
+	// replicas of the Deployment are left to others, like an HPA targeting
+	// the Deployment, and default to 1; the scale subresource of the Arman
+	// only works with the field set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Env are the environment variables of the container.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// EnvFrom are the ConfigMaps and Secrets whose keys become environment
+	// variables of the container.
+	// +optional
+	// +listType=atomic
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// ConfigMounts are the ConfigMaps and Secrets mounted as files into the
+	// container.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ConfigMounts []ConfigMount `json:"configMounts,omitempty"`
+
+	// Resources are the compute resources of the container. Requests and
+	// limits left unset are taken from the defaults of the controller.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// LivenessProbe restarts the container when it fails. Defaults to the
+	// one of the controller, if any.
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// ReadinessProbe takes the pod out of the Service while it fails.
+	// Defaults to the one of the controller, if any.
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+	// StartupProbe holds off the other probes until it succeeds. Defaults to
+	// the one of the controller, if any.
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+	// Lifecycle are the hooks run after the container starts and before it
+	// is stopped, like a preStop hook that lets the endpoints converge
+	// during a rollout. Defaults to the one of the controller, if any.
+	// +optional
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+	// VolumeMounts mount Volumes into the container.
+	// +optional
+	// +listType=map
+	// +listMapKey=mountPath
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// InitContainers run to completion, in order, before the container and
+	// the sidecars start, like a database migration.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+	// Sidecars run next to the container in every pod, like a log shipper
+	// or a proxy.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+	// Volumes are the volumes of the pods, shared by the container, the
+	// sidecars and the init containers that mount them.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+}
+
+// ConfigMount mounts the keys of a ConfigMap or a Secret as files into the
+// container. Exactly one of ConfigMap and Secret is set.
+// +kubebuilder:validation:XValidation:rule="has(self.configMap) != has(self.secret)",message="exactly one of configMap and secret must be set"
+type ConfigMount struct {
+	// Name is the name of the volume holding the files.
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// MountPath is the absolute path the files are mounted at. The mount is
+	// read-only.
+	// +kubebuilder:validation:MinLength=1
+	MountPath string `json:"mountPath"`
+	// ConfigMap is the ConfigMap whose keys become files.
+	// +optional
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+	// Secret is the Secret whose keys become files.
+	// +optional
+	Secret *corev1.SecretVolumeSource `json:"secret,omitempty"`
+}
+
+// ServiceSpec describes the Service of an Arman.
+// +kubebuilder:validation:XValidation:rule="(!has(self.nodePort) && (!has(self.ports) || self.ports.all(p, !has(p.nodePort)))) || (has(self.type) && self.type == 'NodePort')",message="nodePort may only be set when type is NodePort"
+// +kubebuilder:validation:XValidation:rule="has(self.port) || (has(self.ports) && size(self.ports) > 0)",message="either port or ports must be set"
+type ServiceSpec struct {
+	// Name is the name of the Service. Defaults to the name of the Arman.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name,omitempty"`
+	// Type is the type of the Service. Defaults to ClusterIP.
+	// +optional
+	// +kubebuilder:default=ClusterIP
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	Type string `json:"type,omitempty"`
+	// Ports are the ports of the Service and of the container behind it.
+	// Defaults to the single port described by Port, TargetPort and
+	// NodePort.
+	// +optional
+	// +listType=map
+	// +listMapKey=port
+	// +listMapKey=protocol
+	// +kubebuilder:validation:MaxItems=100
+	Ports []ServicePort `json:"ports,omitempty"`
+
+	// Port is the port the Service listens on, for a Service with a single
+	// port. If Ports is set too, it must match the first of them.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+	// TargetPort is the port the container listens on, for a Service with a
+	// single port. Defaults to Port.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	TargetPort int32 `json:"targetPort,omitempty"`
+	// NodePort is the port the Service is exposed on on every node, for a
+	// Service with a single port. Only allowed with Type NodePort; allocated
+	// by the API server if unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+// ServicePort is a port of the Service of an Arman and the port of the
+// container it targets.
+type ServicePort struct {
+	// Name is the name of the port, required if there are several. It names
+	// the container port too.
+	// +optional
+	// +kubebuilder:validation:MaxLength=15
+	Name string `json:"name,omitempty"`
+	// Port is the port the Service listens on.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+	// TargetPort is the port the container listens on, by number or by the
+	// name of another port with a numeric TargetPort. Defaults to Port.
+	// +optional
+	TargetPort intstr.IntOrString `json:"targetPort,omitempty"`
+	// Protocol is the protocol of the port. Defaults to TCP.
+	// +optional
+	// +kubebuilder:default=TCP
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// AppProtocol is the application protocol of the port, like http or
+	// kubernetes.io/h2c.
+	// +optional
+	AppProtocol *string `json:"appProtocol,omitempty"`
+	// NodePort is the port the Service is exposed on on every node. Only
+	// allowed with Type NodePort; allocated by the API server if unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+type ArmanStatus struct {
+	// ObservedGeneration is the generation of the Arman the status was
+	// computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe the current state of the Arman. The known types
+	// are Ready, Progressing, Degraded and ResourceConflict.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// DeploymentName is the name of the Deployment managed for the Arman.
+	// +optional
+	DeploymentName string `json:"deploymentName,omitempty"`
+	// ServiceName is the name of the Service managed for the Arman.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+	// Endpoint is the address the Service can be reached at.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Replicas is the number of pods of the Deployment, for the scale
+	// subresource.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Selector is the label selector of the pods of the Deployment, in
+	// string form, for the scale subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	AvailableReplicas int32 `json:"availableReplicas"`
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+}
+
+// These are the condition types of an Arman.
+const (
+	// ConditionReady is true when the Deployment is fully rolled out and
+	// available and the Service exists.
+	ConditionReady = "Ready"
+	// ConditionProgressing is true while the Deployment is rolling out.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is true when the Arman could not be synced or its
+	// Deployment failed to make progress.
+	ConditionDegraded = "Degraded"
+	// ConditionResourceConflict is true when a child resource is owned by
+	// someone else, either as a whole or through conflicting fields.
+	ConditionResourceConflict = "ResourceConflict"
+)
+
+// DeletionPolicy decides what happens to the children of an Arman when it
+// is deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan;RetainService
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Deployment and the Service.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan removes the owner references from the Deployment
+	// and the Service and leaves them running.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyRetainService deletes the Deployment but orphans the
+	// Service, so that its DNS name stays stable.
+	DeletionPolicyRetainService DeletionPolicy = "RetainService"
+)
+
+// RenamePolicy decides whether the children of an Arman may be renamed.
+// +kubebuilder:validation:Enum=Forbid;Allow
+type RenamePolicy string
+
+const (
+	// RenamePolicyForbid keeps Workload.Name and Service.Name immutable.
+	RenamePolicyForbid RenamePolicy = "Forbid"
+	// RenamePolicyAllow lets Workload.Name and Service.Name be changed. The
+	// children under the old names are then deleted.
+	RenamePolicyAllow RenamePolicy = "Allow"
+)
+
+// ConfigHashAnnotation is put on the pod template of the Deployment of an
+// Arman with a hash of the ConfigMaps and Secrets it references, so that
+// changing them rolls the pods.
+const ConfigHashAnnotation = "arman.com/config-hash"
+
+// LastUsedGenerationAnnotation is put on the ConfigMaps and Secrets holding
+// the ConfigFiles and SecretFiles of an Arman with the generation of the
+// Arman that last used them, so that the superseded ones are pruned least
+// recently used first and files reverted to are kept.
+const LastUsedGenerationAnnotation = "arman.com/last-used-generation"
+
+// ContainerName is the name of the container running Workload.Image, which
+// the sidecars and init containers cannot take.
+const ContainerName = "arman"
+
+// The volumes and mount paths of the ConfigFiles and SecretFiles of an
+// Arman in its container.
+const (
+	ConfigFilesVolumeName = "arman-config-files"
+	ConfigFilesMountPath  = "/etc/arman/config"
+	SecretFilesVolumeName = "arman-secret-files"
+	SecretFilesMountPath  = "/etc/arman/secrets"
+)
+
+// CleanupFinalizer is put on every Arman by the controller, so that its
+// children are cleaned up according to its DeletionPolicy before it is
+// removed.
+const CleanupFinalizer = "arman.com/cleanup"
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ArmanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Arman `json:"items,omitempty"`
+}
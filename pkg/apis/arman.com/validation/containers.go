@@ -0,0 +1,189 @@
+package validation
+
+import (
+	"path"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// validateContainers validates the volumes, volume mounts, sidecars and
+// init containers of spec, found at fldPath. mainPortNames are the names of
+// the ports of the container running Workload.Image. It returns the names
+// of the ports of the sidecars, which the ports of the Service may target.
+func validateContainers(spec *myv1beta1.WorkloadSpec, mainPortNames sets.Set[string], fldPath *field.Path) (field.ErrorList, sets.Set[string]) {
+	allErrs := field.ErrorList{}
+
+	// The volumes of the config mounts and the inline files can be mounted
+	// like the others.
+	volumeNames := sets.New(myv1beta1.ConfigFilesVolumeName, myv1beta1.SecretFilesVolumeName)
+	for _, mount := range spec.ConfigMounts {
+		volumeNames.Insert(mount.Name)
+	}
+	allErrs = append(allErrs, validateVolumes(spec.Volumes, volumeNames, fldPath.Child("volumes"))...)
+	for _, volume := range spec.Volumes {
+		volumeNames.Insert(volume.Name)
+	}
+
+	// The config mounts and the inline files are mounted into the
+	// container already.
+	mountPaths := sets.New(myv1beta1.ConfigFilesMountPath, myv1beta1.SecretFilesMountPath)
+	for _, mount := range spec.ConfigMounts {
+		mountPaths.Insert(path.Clean(mount.MountPath))
+	}
+	allErrs = append(allErrs, validateVolumeMounts(spec.VolumeMounts, volumeNames, mountPaths, fldPath.Child("volumeMounts"))...)
+
+	containerNames := sets.New(myv1beta1.ContainerName)
+	portNames := mainPortNames.Clone()
+	for i := range spec.Sidecars {
+		allErrs = append(allErrs, validateContainer(&spec.Sidecars[i], false, containerNames, portNames, volumeNames, fldPath.Child("sidecars").Index(i))...)
+	}
+	sidecarPortNames := portNames.Difference(mainPortNames)
+	for i := range spec.InitContainers {
+		allErrs = append(allErrs, validateContainer(&spec.InitContainers[i], true, containerNames, portNames, volumeNames, fldPath.Child("initContainers").Index(i))...)
+	}
+	return allErrs, sidecarPortNames
+}
+
+// validateContainer validates a sidecar or an init container, found at
+// fldPath. Its name and the names of its ports must not be in
+// containerNames and portNames, which they are added to.
+func validateContainer(container *corev1.Container, init bool, containerNames, portNames, volumeNames sets.Set[string], fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if container.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(container.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), container.Name, msg))
+		}
+		if containerNames.Has(container.Name) {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("name"), container.Name))
+		}
+		containerNames.Insert(container.Name)
+	}
+	allErrs = append(allErrs, validateImage(container.Image, fldPath.Child("image"))...)
+
+	for i, port := range container.Ports {
+		idxPath := fldPath.Child("ports").Index(i)
+		allErrs = append(allErrs, validatePort(port.ContainerPort, idxPath.Child("containerPort"))...)
+		if port.Protocol != "" && !supportedProtocols.Has(string(port.Protocol)) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("protocol"), port.Protocol, sets.List(supportedProtocols)))
+		}
+		if port.Name == "" {
+			continue
+		}
+		for _, msg := range validation.IsValidPortName(port.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), port.Name, msg))
+		}
+		// Ports are targeted by name across the containers of the pod, so
+		// the names are unique in it.
+		if portNames.Has(port.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), port.Name))
+		}
+		portNames.Insert(port.Name)
+	}
+
+	allErrs = append(allErrs, validateEnv(container.Env, fldPath.Child("env"))...)
+	allErrs = append(allErrs, validateEnvFrom(container.EnvFrom, fldPath.Child("envFrom"))...)
+	allErrs = append(allErrs, ValidateResourceRequirements(&container.Resources, fldPath.Child("resources"))...)
+	allErrs = append(allErrs, validateVolumeMounts(container.VolumeMounts, volumeNames, sets.New[string](), fldPath.Child("volumeMounts"))...)
+
+	// Init containers run to completion, so they are neither probed nor
+	// stopped.
+	if init {
+		for _, f := range []struct {
+			name string
+			set  bool
+		}{
+			{"livenessProbe", container.LivenessProbe != nil},
+			{"readinessProbe", container.ReadinessProbe != nil},
+			{"startupProbe", container.StartupProbe != nil},
+			{"lifecycle", container.Lifecycle != nil},
+		} {
+			if f.set {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child(f.name), "may not be set for init containers"))
+			}
+		}
+		return allErrs
+	}
+	allErrs = append(allErrs, ValidateProbe(container.LivenessProbe, true, fldPath.Child("livenessProbe"))...)
+	allErrs = append(allErrs, ValidateProbe(container.ReadinessProbe, false, fldPath.Child("readinessProbe"))...)
+	allErrs = append(allErrs, ValidateProbe(container.StartupProbe, true, fldPath.Child("startupProbe"))...)
+	allErrs = append(allErrs, ValidateLifecycle(container.Lifecycle, fldPath.Child("lifecycle"))...)
+	return allErrs
+}
+
+// validateVolumes validates the volumes of the pods, found at fldPath. Their
+// names must not be in reservedNames, the names of the volumes the
+// controller adds.
+func validateVolumes(volumes []corev1.Volume, reservedNames sets.Set[string], fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	names := sets.New[string]()
+	for i, volume := range volumes {
+		idxPath := fldPath.Index(i)
+		if volume.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Label(volume.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), volume.Name, msg))
+			}
+			if names.Has(volume.Name) || reservedNames.Has(volume.Name) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), volume.Name))
+			}
+			names.Insert(volume.Name)
+		}
+		if numVolumeSources(&volume.VolumeSource) != 1 {
+			allErrs = append(allErrs, field.Invalid(idxPath, "", "must specify exactly one volume source"))
+		}
+	}
+	return allErrs
+}
+
+// numVolumeSources returns the number of sources set in source, which are
+// all pointers.
+func numVolumeSources(source *corev1.VolumeSource) int {
+	n := 0
+	v := reflect.ValueOf(source).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Field(i).IsNil() {
+			n++
+		}
+	}
+	return n
+}
+
+// validateVolumeMounts validates the volume mounts of a container, found at
+// fldPath. They must mount one of volumeNames, and not at one of usedPaths,
+// the paths mounted into the container already.
+func validateVolumeMounts(mounts []corev1.VolumeMount, volumeNames, usedPaths sets.Set[string], fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	mountPaths := usedPaths.Clone()
+	for i, mount := range mounts {
+		idxPath := fldPath.Index(i)
+		if mount.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if !volumeNames.Has(mount.Name) {
+			allErrs = append(allErrs, field.NotFound(idxPath.Child("name"), mount.Name))
+		}
+		if mount.MountPath == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("mountPath"), ""))
+			continue
+		}
+		if !path.IsAbs(mount.MountPath) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("mountPath"), mount.MountPath, "must be an absolute path"))
+		}
+		if mountPaths.Has(path.Clean(mount.MountPath)) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("mountPath"), mount.MountPath))
+		}
+		mountPaths.Insert(path.Clean(mount.MountPath))
+	}
+	return allErrs
+}
@@ -0,0 +1,319 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// crdRule is a CEL rule of the Arman CRD with the version and the path of
+// the schema node it validates.
+type crdRule struct {
+	version string
+	path    string
+	apiextensionsv1.ValidationRule
+}
+
+func (r crdRule) String() string {
+	return fmt.Sprintf("%s %s: %s", r.version, r.path, r.Message)
+}
+
+// crdRules returns the CEL rules of every version of the generated CRD.
+func crdRules(t *testing.T) []crdRule {
+	t.Helper()
+	data, err := os.ReadFile("../../../../manifests/arman.com_armans.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.UnmarshalStrict(data, crd); err != nil {
+		t.Fatal(err)
+	}
+
+	var rules []crdRule
+	var walk func(version, path string, schema *apiextensionsv1.JSONSchemaProps)
+	walk = func(version, path string, schema *apiextensionsv1.JSONSchemaProps) {
+		for _, rule := range schema.XValidations {
+			rules = append(rules, crdRule{version, path, rule})
+		}
+		for name, property := range schema.Properties {
+			property := property
+			walk(version, path+"."+name, &property)
+		}
+		if schema.Items != nil && schema.Items.Schema != nil {
+			walk(version, path+"[*]", schema.Items.Schema)
+		}
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			walk(version, path+"[*]", schema.AdditionalProperties.Schema)
+		}
+	}
+	for _, version := range crd.Spec.Versions {
+		walk(version.Name, "self", version.Schema.OpenAPIV3Schema)
+	}
+	return rules
+}
+
+// evalRule compiles rule and evaluates it against self and, unless it is
+// empty, oldSelf, both given as JSON.
+func evalRule(t *testing.T, rule crdRule, self, oldSelf string) bool {
+	t.Helper()
+	env, err := cel.NewEnv(cel.Variable("self", cel.DynType), cel.Variable("oldSelf", cel.DynType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast, issues := env.Compile(rule.Rule)
+	if issues.Err() != nil {
+		t.Fatalf("compiling %s: %v", rule, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		t.Fatalf("%s returns %s, want bool", rule, ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		t.Fatalf("%s: %v", rule, err)
+	}
+
+	vars := map[string]interface{}{}
+	for name, value := range map[string]string{"self": self, "oldSelf": oldSelf} {
+		if value == "" {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			t.Fatalf("decoding %s %s: %v", name, value, err)
+		}
+		vars[name] = v
+	}
+	out, _, err := program.Eval(vars)
+	if err != nil {
+		t.Fatalf("evaluating %s: %v", rule, err)
+	}
+	valid, ok := out.Value().(bool)
+	if !ok {
+		t.Fatalf("%s = %v, want a bool", rule, out)
+	}
+	return valid
+}
+
+// TestCRDValidationRules compiles every CEL rule of the generated CRD and
+// evaluates it against Armans it must accept and reject, so that a typo in
+// a rule fails here instead of in the API server.
+func TestCRDValidationRules(t *testing.T) {
+	const (
+		renameV1alpha1   = "v1alpha1 self: spec.deploymentName and spec.serviceName are immutable unless spec.renamePolicy is Allow"
+		nodePortV1alpha1 = "v1alpha1 self.spec: nodePort may only be set when serviceType is NodePort"
+		renameV1beta1    = "v1beta1 self: spec.workload.name and spec.service.name are immutable unless spec.renamePolicy is Allow"
+		configMount      = "v1beta1 self.spec.workload.configMounts[*]: exactly one of configMap and secret must be set"
+		nodePortV1beta1  = "v1beta1 self.spec.service: nodePort may only be set when type is NodePort"
+		servicePort      = "v1beta1 self.spec.service: either port or ports must be set"
+	)
+
+	tests := []struct {
+		rule    string
+		name    string
+		self    string
+		oldSelf string
+		want    bool
+	}{
+		{
+			rule:    renameV1alpha1,
+			name:    "unchanged",
+			self:    `{"metadata": {"name": "web"}, "spec": {"deploymentName": "web", "serviceName": "web"}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"deploymentName": "web", "serviceName": "web"}}`,
+			want:    true,
+		},
+		{
+			rule:    renameV1alpha1,
+			name:    "defaulted names set to the Arman name",
+			self:    `{"metadata": {"name": "web"}, "spec": {"deploymentName": "web", "serviceName": "web"}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {}}`,
+			want:    true,
+		},
+		{
+			rule:    renameV1alpha1,
+			name:    "deployment renamed",
+			self:    `{"metadata": {"name": "web"}, "spec": {"deploymentName": "api", "serviceName": "web"}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"deploymentName": "web", "serviceName": "web"}}`,
+		},
+		{
+			rule:    renameV1alpha1,
+			name:    "service renamed",
+			self:    `{"metadata": {"name": "web"}, "spec": {"deploymentName": "web", "serviceName": "api"}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"deploymentName": "web"}}`,
+		},
+		{
+			rule:    renameV1alpha1,
+			name:    "renamed with renamePolicy Allow",
+			self:    `{"metadata": {"name": "web"}, "spec": {"deploymentName": "api", "serviceName": "api"}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"deploymentName": "web", "serviceName": "web", "renamePolicy": "Allow"}}`,
+			want:    true,
+		},
+		{
+			rule: nodePortV1alpha1,
+			name: "no nodePort",
+			self: `{"serviceType": "ClusterIP"}`,
+			want: true,
+		},
+		{
+			rule: nodePortV1alpha1,
+			name: "nodePort with NodePort",
+			self: `{"serviceType": "NodePort", "nodePort": 30080}`,
+			want: true,
+		},
+		{
+			rule: nodePortV1alpha1,
+			name: "nodePort with ClusterIP",
+			self: `{"serviceType": "ClusterIP", "nodePort": 30080}`,
+		},
+		{
+			rule: nodePortV1alpha1,
+			name: "nodePort without serviceType",
+			self: `{"nodePort": 30080}`,
+		},
+		{
+			rule:    renameV1beta1,
+			name:    "unchanged",
+			self:    `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {"name": "web"}}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {"name": "web"}}}`,
+			want:    true,
+		},
+		{
+			rule:    renameV1beta1,
+			name:    "defaulted names set to the Arman name",
+			self:    `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {"name": "web"}}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"workload": {}, "service": {}}}`,
+			want:    true,
+		},
+		{
+			rule:    renameV1beta1,
+			name:    "workload renamed",
+			self:    `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "api"}, "service": {"name": "web"}}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {"name": "web"}}}`,
+		},
+		{
+			rule:    renameV1beta1,
+			name:    "service renamed",
+			self:    `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {"name": "api"}}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {}}}`,
+		},
+		{
+			rule:    renameV1beta1,
+			name:    "renamed with renamePolicy Allow",
+			self:    `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "api"}, "service": {"name": "api"}}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {"name": "web"}, "renamePolicy": "Allow"}}`,
+			want:    true,
+		},
+		{
+			rule:    renameV1beta1,
+			name:    "renamed with renamePolicy Forbid",
+			self:    `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "api"}, "service": {"name": "web"}}}`,
+			oldSelf: `{"metadata": {"name": "web"}, "spec": {"workload": {"name": "web"}, "service": {"name": "web"}, "renamePolicy": "Forbid"}}`,
+		},
+		{
+			rule: configMount,
+			name: "configMap",
+			self: `{"name": "config", "mountPath": "/etc/web", "configMap": {"name": "web"}}`,
+			want: true,
+		},
+		{
+			rule: configMount,
+			name: "secret",
+			self: `{"name": "config", "mountPath": "/etc/web", "secret": {"secretName": "web"}}`,
+			want: true,
+		},
+		{
+			rule: configMount,
+			name: "configMap and secret",
+			self: `{"name": "config", "mountPath": "/etc/web", "configMap": {"name": "web"}, "secret": {"secretName": "web"}}`,
+		},
+		{
+			rule: configMount,
+			name: "neither",
+			self: `{"name": "config", "mountPath": "/etc/web"}`,
+		},
+		{
+			rule: nodePortV1beta1,
+			name: "no nodePort",
+			self: `{"type": "ClusterIP", "port": 80, "ports": [{"name": "http", "port": 80}]}`,
+			want: true,
+		},
+		{
+			rule: nodePortV1beta1,
+			name: "nodePort with NodePort",
+			self: `{"type": "NodePort", "port": 80, "nodePort": 30080}`,
+			want: true,
+		},
+		{
+			rule: nodePortV1beta1,
+			name: "port nodePort with NodePort",
+			self: `{"type": "NodePort", "ports": [{"name": "http", "port": 80, "nodePort": 30080}]}`,
+			want: true,
+		},
+		{
+			rule: nodePortV1beta1,
+			name: "nodePort with ClusterIP",
+			self: `{"type": "ClusterIP", "port": 80, "nodePort": 30080}`,
+		},
+		{
+			rule: nodePortV1beta1,
+			name: "nodePort without type",
+			self: `{"port": 80, "nodePort": 30080}`,
+		},
+		{
+			rule: nodePortV1beta1,
+			name: "port nodePort with ClusterIP",
+			self: `{"type": "ClusterIP", "ports": [{"name": "http", "port": 80}, {"name": "metrics", "port": 9090, "nodePort": 30090}]}`,
+		},
+		{
+			rule: servicePort,
+			name: "port",
+			self: `{"port": 80}`,
+			want: true,
+		},
+		{
+			rule: servicePort,
+			name: "ports",
+			self: `{"ports": [{"name": "http", "port": 80}]}`,
+			want: true,
+		},
+		{
+			rule: servicePort,
+			name: "empty ports",
+			self: `{"ports": []}`,
+		},
+		{
+			rule: servicePort,
+			name: "neither",
+			self: `{"type": "ClusterIP"}`,
+		},
+	}
+
+	rules := map[string]crdRule{}
+	for _, rule := range crdRules(t) {
+		rules[rule.String()] = rule
+	}
+	tested := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.rule+"/"+tt.name, func(t *testing.T) {
+			rule, ok := rules[tt.rule]
+			if !ok {
+				t.Fatalf("no rule %q in the CRD", tt.rule)
+			}
+			tested[tt.rule] = true
+			if got := evalRule(t, rule, tt.self, tt.oldSelf); got != tt.want {
+				t.Errorf("%s = %t, want %t", rule.Rule, got, tt.want)
+			}
+		})
+	}
+	// A new rule needs cases too.
+	for name := range rules {
+		if !tested[name] {
+			t.Errorf("rule %q is not tested", name)
+		}
+	}
+}
@@ -0,0 +1,672 @@
+// Package validation checks Arman objects the way the API server checks
+// built-in types, reporting every problem with the path of the field at
+// fault.
+package validation
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// maxImageNameLength is the longest repository name, without tag and
+// digest, an image reference may have.
+const maxImageNameLength = 255
+
+// imageReferenceRegexp matches image references like the container runtimes
+// accept them: [domain[:port]/]path[:tag][@digest]. The name is captured.
+var imageReferenceRegexp = func() *regexp.Regexp {
+	const (
+		alphanumeric    = `[a-z0-9]+`
+		separator       = `(?:[._]|__|[-]+)`
+		pathComponent   = alphanumeric + `(?:` + separator + alphanumeric + `)*`
+		domainComponent = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+		domain          = domainComponent + `(?:\.` + domainComponent + `)*(?::[0-9]+)?`
+		name            = `(?:` + domain + `/)?` + pathComponent + `(?:/` + pathComponent + `)*`
+		tag             = `[\w][\w.-]{0,127}`
+		digest          = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}`
+	)
+	return regexp.MustCompile(`^(` + name + `)(?::` + tag + `)?(?:@` + digest + `)?$`)
+}()
+
+// maxFilesSize is the most data the ConfigFiles or the SecretFiles of an
+// Arman may hold, which is what fits into a ConfigMap or a Secret.
+const maxFilesSize = corev1.MaxSecretSize
+
+// maxFilesOwnerNameLength is the longest Deployment name an Arman with
+// inline files may have: the ConfigMap and Secret holding them are named
+// after it, with "-secret-" and a hash of 10 hex digits appended.
+const maxFilesOwnerNameLength = validation.DNS1123SubdomainMaxLength - len("-secret-") - 10
+
+// supportedServiceTypes are the Service types an Arman can have. An
+// ExternalName Service has no selector, so it cannot front the Deployment.
+var supportedServiceTypes = sets.New(
+	string(corev1.ServiceTypeClusterIP),
+	string(corev1.ServiceTypeNodePort),
+	string(corev1.ServiceTypeLoadBalancer),
+)
+
+// reservedVolumeNames and reservedMountPaths are the ones of the volumes
+// holding the inline files of an Arman, which config mounts cannot use.
+var (
+	reservedVolumeNames = sets.New(myv1beta1.ConfigFilesVolumeName, myv1beta1.SecretFilesVolumeName)
+	reservedMountPaths  = sets.New(myv1beta1.ConfigFilesMountPath, myv1beta1.SecretFilesMountPath)
+)
+
+var supportedDeletionPolicies = sets.New(
+	string(myv1beta1.DeletionPolicyDelete),
+	string(myv1beta1.DeletionPolicyOrphan),
+	string(myv1beta1.DeletionPolicyRetainService),
+)
+
+var supportedRenamePolicies = sets.New(
+	string(myv1beta1.RenamePolicyForbid),
+	string(myv1beta1.RenamePolicyAllow),
+)
+
+// ValidateArman validates a new Arman.
+func ValidateArman(arman *myv1beta1.Arman) field.ErrorList {
+	return ValidateArmanSpec(&arman.Spec, field.NewPath("spec"))
+}
+
+// ValidateArmanUpdate validates an update of oldArman to arman. On top of
+// what ValidateArman checks, the names of the children may only change if
+// the rename policy of oldArman allows it, so that a rename cannot sneak in
+// with the policy change.
+//
+// An unchanged spec is not validated again, so that Armans created before
+// validation was in place can still have their metadata, like finalizers,
+// updated.
+func ValidateArmanUpdate(arman, oldArman *myv1beta1.Arman) field.ErrorList {
+	if equality.Semantic.DeepEqual(arman.Spec, oldArman.Spec) {
+		return nil
+	}
+	allErrs := ValidateArman(arman)
+
+	if oldArman.Spec.RenamePolicy != myv1beta1.RenamePolicyAllow {
+		specPath := field.NewPath("spec")
+		msg := fmt.Sprintf("field is immutable unless renamePolicy is %s", myv1beta1.RenamePolicyAllow)
+		// An unset name stands for the name of the Arman, so setting it to
+		// that is not a rename.
+		if childName(arman.Spec.Workload.Name, arman) != childName(oldArman.Spec.Workload.Name, oldArman) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("workload", "name"), arman.Spec.Workload.Name, msg))
+		}
+		if childName(arman.Spec.Service.Name, arman) != childName(oldArman.Spec.Service.Name, oldArman) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("service", "name"), arman.Spec.Service.Name, msg))
+		}
+	}
+	return allErrs
+}
+
+// childName returns the name a child of arman named name in its spec gets.
+func childName(name string, arman *myv1beta1.Arman) string {
+	if name == "" {
+		return arman.Name
+	}
+	return name
+}
+
+// ValidateArmanSpec validates spec, found at fldPath.
+func ValidateArmanSpec(spec *myv1beta1.ArmanSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateWorkloadSpec(&spec.Workload, fldPath.Child("workload"))...)
+	containerErrs, sidecarPortNames := validateContainers(&spec.Workload, containerPortNames(spec.Service.Ports), fldPath.Child("workload"))
+	allErrs = append(allErrs, containerErrs...)
+	allErrs = append(allErrs, validateServiceSpec(&spec.Service, sidecarPortNames, fldPath.Child("service"))...)
+	allErrs = append(allErrs, validateFiles(spec.ConfigFiles, fldPath.Child("configFiles"))...)
+	allErrs = append(allErrs, validateFiles(spec.SecretFiles, fldPath.Child("secretFiles"))...)
+	if (len(spec.ConfigFiles) > 0 || len(spec.SecretFiles) > 0) && len(spec.Workload.Name) > maxFilesOwnerNameLength {
+		allErrs = append(allErrs, field.TooLong(fldPath.Child("workload", "name"), spec.Workload.Name, maxFilesOwnerNameLength))
+	}
+	if spec.ConfigRevisionHistoryLimit != nil && *spec.ConfigRevisionHistoryLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("configRevisionHistoryLimit"), *spec.ConfigRevisionHistoryLimit, "must be greater than or equal to 0"))
+	}
+
+	// Empty policies are defaulted.
+	if spec.DeletionPolicy != "" && !supportedDeletionPolicies.Has(string(spec.DeletionPolicy)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("deletionPolicy"), spec.DeletionPolicy, sets.List(supportedDeletionPolicies)))
+	}
+	if spec.RenamePolicy != "" && !supportedRenamePolicies.Has(string(spec.RenamePolicy)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("renamePolicy"), spec.RenamePolicy, sets.List(supportedRenamePolicies)))
+	}
+	return allErrs
+}
+
+func validateWorkloadSpec(spec *myv1beta1.WorkloadSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), spec.Name, msg))
+		}
+	}
+	allErrs = append(allErrs, validateImage(spec.Image, fldPath.Child("image"))...)
+	if spec.Replicas != nil && *spec.Replicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), *spec.Replicas, "must be greater than or equal to 0"))
+	}
+	allErrs = append(allErrs, validateEnv(spec.Env, fldPath.Child("env"))...)
+	allErrs = append(allErrs, validateEnvFrom(spec.EnvFrom, fldPath.Child("envFrom"))...)
+	allErrs = append(allErrs, validateConfigMounts(spec.ConfigMounts, fldPath.Child("configMounts"))...)
+	allErrs = append(allErrs, ValidateResourceRequirements(&spec.Resources, fldPath.Child("resources"))...)
+	allErrs = append(allErrs, ValidateProbe(spec.LivenessProbe, true, fldPath.Child("livenessProbe"))...)
+	allErrs = append(allErrs, ValidateProbe(spec.ReadinessProbe, false, fldPath.Child("readinessProbe"))...)
+	allErrs = append(allErrs, ValidateProbe(spec.StartupProbe, true, fldPath.Child("startupProbe"))...)
+	allErrs = append(allErrs, ValidateLifecycle(spec.Lifecycle, fldPath.Child("lifecycle"))...)
+	return allErrs
+}
+
+// ValidateResourceRequirements validates the resources of a container,
+// found at fldPath. Resource claims are not supported, the pods of an Arman
+// have none.
+func ValidateResourceRequirements(resources *corev1.ResourceRequirements, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for name, quantity := range resources.Limits {
+		allErrs = append(allErrs, validateResourceQuantity(name, quantity, fldPath.Child("limits").Key(string(name)))...)
+	}
+	for name, quantity := range resources.Requests {
+		keyPath := fldPath.Child("requests").Key(string(name))
+		allErrs = append(allErrs, validateResourceQuantity(name, quantity, keyPath)...)
+		if limit, ok := resources.Limits[name]; ok && quantity.Cmp(limit) > 0 {
+			allErrs = append(allErrs, field.Invalid(keyPath, quantity.String(), fmt.Sprintf("must be less than or equal to %s limit of %s", name, limit.String())))
+		}
+	}
+	if len(resources.Claims) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("claims"), "resource claims are not supported"))
+	}
+	return allErrs
+}
+
+func validateResourceQuantity(name corev1.ResourceName, quantity resource.Quantity, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsQualifiedName(string(name)) {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, msg))
+	}
+	if quantity.Sign() < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath, quantity.String(), "must be greater than or equal to 0"))
+	}
+	return allErrs
+}
+
+// ValidateProbe validates probe, found at fldPath, if it is set. Liveness
+// and startup probes need a single success.
+func ValidateProbe(probe *corev1.Probe, singleSuccess bool, fldPath *field.Path) field.ErrorList {
+	if probe == nil {
+		return nil
+	}
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateProbeHandler(&probe.ProbeHandler, fldPath)...)
+	for _, f := range []struct {
+		name  string
+		value int32
+	}{
+		{"initialDelaySeconds", probe.InitialDelaySeconds},
+		{"timeoutSeconds", probe.TimeoutSeconds},
+		{"periodSeconds", probe.PeriodSeconds},
+		{"successThreshold", probe.SuccessThreshold},
+		{"failureThreshold", probe.FailureThreshold},
+	} {
+		if f.value < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(f.name), f.value, "must be greater than or equal to 0"))
+		}
+	}
+	if singleSuccess && probe.SuccessThreshold > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("successThreshold"), probe.SuccessThreshold, "must be 1"))
+	}
+	if probe.TerminationGracePeriodSeconds != nil && *probe.TerminationGracePeriodSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("terminationGracePeriodSeconds"), *probe.TerminationGracePeriodSeconds, "must be greater than 0"))
+	}
+	return allErrs
+}
+
+func validateProbeHandler(handler *corev1.ProbeHandler, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	handlers := 0
+	if handler.Exec != nil {
+		handlers++
+		allErrs = append(allErrs, validateExecAction(handler.Exec, fldPath.Child("exec"))...)
+	}
+	if handler.HTTPGet != nil {
+		handlers++
+		allErrs = append(allErrs, validateHTTPGetAction(handler.HTTPGet, fldPath.Child("httpGet"))...)
+	}
+	if handler.TCPSocket != nil {
+		handlers++
+		allErrs = append(allErrs, validatePortNumOrName(handler.TCPSocket.Port, fldPath.Child("tcpSocket", "port"))...)
+	}
+	if handler.GRPC != nil {
+		handlers++
+		allErrs = append(allErrs, validatePort(handler.GRPC.Port, fldPath.Child("grpc", "port"))...)
+	}
+	if handlers != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, "", "must specify exactly one of: `exec`, `httpGet`, `tcpSocket` or `grpc`"))
+	}
+	return allErrs
+}
+
+// ValidateLifecycle validates the lifecycle hooks of a container, found at
+// fldPath, if they are set.
+func ValidateLifecycle(lifecycle *corev1.Lifecycle, fldPath *field.Path) field.ErrorList {
+	if lifecycle == nil {
+		return nil
+	}
+	allErrs := field.ErrorList{}
+	for _, hook := range []struct {
+		name    string
+		handler *corev1.LifecycleHandler
+	}{
+		{"postStart", lifecycle.PostStart},
+		{"preStop", lifecycle.PreStop},
+	} {
+		if hook.handler == nil {
+			continue
+		}
+		hookPath := fldPath.Child(hook.name)
+		handlers := 0
+		if hook.handler.Exec != nil {
+			handlers++
+			allErrs = append(allErrs, validateExecAction(hook.handler.Exec, hookPath.Child("exec"))...)
+		}
+		if hook.handler.HTTPGet != nil {
+			handlers++
+			allErrs = append(allErrs, validateHTTPGetAction(hook.handler.HTTPGet, hookPath.Child("httpGet"))...)
+		}
+		if hook.handler.TCPSocket != nil {
+			handlers++
+			allErrs = append(allErrs, validatePortNumOrName(hook.handler.TCPSocket.Port, hookPath.Child("tcpSocket", "port"))...)
+		}
+		if handlers != 1 {
+			allErrs = append(allErrs, field.Invalid(hookPath, "", "must specify exactly one of: `exec`, `httpGet` or `tcpSocket`"))
+		}
+	}
+	return allErrs
+}
+
+func validateExecAction(exec *corev1.ExecAction, fldPath *field.Path) field.ErrorList {
+	if len(exec.Command) == 0 {
+		return field.ErrorList{field.Required(fldPath.Child("command"), "")}
+	}
+	return nil
+}
+
+var supportedHTTPSchemes = sets.New(string(corev1.URISchemeHTTP), string(corev1.URISchemeHTTPS))
+
+func validateHTTPGetAction(http *corev1.HTTPGetAction, fldPath *field.Path) field.ErrorList {
+	allErrs := validatePortNumOrName(http.Port, fldPath.Child("port"))
+	if http.Scheme != "" && !supportedHTTPSchemes.Has(string(http.Scheme)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("scheme"), http.Scheme, sets.List(supportedHTTPSchemes)))
+	}
+	for i, header := range http.HTTPHeaders {
+		for _, msg := range validation.IsHTTPHeaderName(header.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("httpHeaders").Index(i).Child("name"), header.Name, msg))
+		}
+	}
+	return allErrs
+}
+
+// validatePortNumOrName validates a port given by number or by the name of
+// a port of the container.
+func validatePortNumOrName(port intstr.IntOrString, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch port.Type {
+	case intstr.Int:
+		for _, msg := range validation.IsValidPortNum(port.IntValue()) {
+			allErrs = append(allErrs, field.Invalid(fldPath, port.IntValue(), msg))
+		}
+	case intstr.String:
+		for _, msg := range validation.IsValidPortName(port.StrVal) {
+			allErrs = append(allErrs, field.Invalid(fldPath, port.StrVal, msg))
+		}
+	default:
+		allErrs = append(allErrs, field.InternalError(fldPath, fmt.Errorf("unknown type: %v", port.Type)))
+	}
+	return allErrs
+}
+
+func validateEnv(env []corev1.EnvVar, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	names := sets.New[string]()
+	for i, ev := range env {
+		idxPath := fldPath.Index(i)
+		if ev.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else {
+			for _, msg := range validation.IsEnvVarName(ev.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), ev.Name, msg))
+			}
+			if names.Has(ev.Name) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), ev.Name))
+			}
+			names.Insert(ev.Name)
+		}
+		if ev.ValueFrom != nil {
+			allErrs = append(allErrs, validateEnvVarSource(ev.ValueFrom, idxPath.Child("valueFrom"))...)
+			if ev.Value != "" {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("valueFrom"), "", "may not be specified when `value` is not empty"))
+			}
+		}
+	}
+	return allErrs
+}
+
+func validateEnvVarSource(source *corev1.EnvVarSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	sources := 0
+	if ref := source.ConfigMapKeyRef; ref != nil {
+		sources++
+		allErrs = append(allErrs, validateKeyRef(ref.Name, ref.Key, fldPath.Child("configMapKeyRef"))...)
+	}
+	if ref := source.SecretKeyRef; ref != nil {
+		sources++
+		allErrs = append(allErrs, validateKeyRef(ref.Name, ref.Key, fldPath.Child("secretKeyRef"))...)
+	}
+	if source.FieldRef != nil {
+		sources++
+	}
+	if source.ResourceFieldRef != nil {
+		sources++
+	}
+	if sources != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, "", "must specify exactly one of: `fieldRef`, `resourceFieldRef`, `configMapKeyRef` or `secretKeyRef`"))
+	}
+	return allErrs
+}
+
+func validateKeyRef(name, key string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	}
+	if key == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("key"), ""))
+	} else {
+		for _, msg := range validation.IsConfigMapKey(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("key"), key, msg))
+		}
+	}
+	return allErrs
+}
+
+func validateEnvFrom(envFrom []corev1.EnvFromSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, ev := range envFrom {
+		idxPath := fldPath.Index(i)
+		if ev.Prefix != "" {
+			for _, msg := range validation.IsEnvVarName(ev.Prefix) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("prefix"), ev.Prefix, msg))
+			}
+		}
+		sources := 0
+		if ev.ConfigMapRef != nil {
+			sources++
+			allErrs = append(allErrs, validateReferenceName(ev.ConfigMapRef.Name, idxPath.Child("configMapRef", "name"))...)
+		}
+		if ev.SecretRef != nil {
+			sources++
+			allErrs = append(allErrs, validateReferenceName(ev.SecretRef.Name, idxPath.Child("secretRef", "name"))...)
+		}
+		if sources != 1 {
+			allErrs = append(allErrs, field.Invalid(idxPath, "", "must specify exactly one of: `configMapRef` or `secretRef`"))
+		}
+	}
+	return allErrs
+}
+
+func validateConfigMounts(mounts []myv1beta1.ConfigMount, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	names, mountPaths := sets.New[string](), sets.New[string]()
+	for i, mount := range mounts {
+		idxPath := fldPath.Index(i)
+		// The name becomes the name of a volume of the pod.
+		if mount.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Label(mount.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), mount.Name, msg))
+			}
+			if names.Has(mount.Name) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), mount.Name))
+			}
+			if reservedVolumeNames.Has(mount.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), mount.Name, "is reserved for the volumes of configFiles and secretFiles"))
+			}
+			names.Insert(mount.Name)
+		}
+		if mount.MountPath == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("mountPath"), ""))
+		} else {
+			if !path.IsAbs(mount.MountPath) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("mountPath"), mount.MountPath, "must be an absolute path"))
+			}
+			if mountPaths.Has(mount.MountPath) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("mountPath"), mount.MountPath))
+			}
+			if reservedMountPaths.Has(path.Clean(mount.MountPath)) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("mountPath"), mount.MountPath, "is reserved for configFiles and secretFiles"))
+			}
+			mountPaths.Insert(mount.MountPath)
+		}
+
+		sources := 0
+		if mount.ConfigMap != nil {
+			sources++
+			allErrs = append(allErrs, validateReferenceName(mount.ConfigMap.Name, idxPath.Child("configMap", "name"))...)
+		}
+		if mount.Secret != nil {
+			sources++
+			allErrs = append(allErrs, validateReferenceName(mount.Secret.SecretName, idxPath.Child("secret", "secretName"))...)
+		}
+		if sources != 1 {
+			allErrs = append(allErrs, field.Invalid(idxPath, "", "must specify exactly one of: `configMap` or `secret`"))
+		}
+	}
+	return allErrs
+}
+
+func validateFiles(files map[string]string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	size := 0
+	for name, content := range files {
+		for _, msg := range validation.IsConfigMapKey(name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(name), name, msg))
+		}
+		size += len(name) + len(content)
+	}
+	if size > maxFilesSize {
+		allErrs = append(allErrs, field.TooLong(fldPath, "", maxFilesSize))
+	}
+	return allErrs
+}
+
+// validateReferenceName validates name, the name of a referenced ConfigMap
+// or Secret.
+func validateReferenceName(name string, fldPath *field.Path) field.ErrorList {
+	if name == "" {
+		return field.ErrorList{field.Required(fldPath, "")}
+	}
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, msg))
+	}
+	return allErrs
+}
+
+func validateServiceSpec(spec *myv1beta1.ServiceSpec, sidecarPortNames sets.Set[string], fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	} else {
+		// Service names become DNS labels, so they are held to the stricter
+		// rules the API server applies to them.
+		for _, msg := range validation.IsDNS1035Label(spec.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), spec.Name, msg))
+		}
+	}
+	if !supportedServiceTypes.Has(spec.Type) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), spec.Type, sets.List(supportedServiceTypes)))
+	}
+
+	// The single port fields are defaulted into Ports, so only an Arman
+	// setting neither has no port.
+	if spec.Port == 0 && len(spec.Ports) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("ports"), "either port or ports must be set"))
+	}
+	if spec.Port != 0 {
+		allErrs = append(allErrs, validatePort(spec.Port, fldPath.Child("port"))...)
+	}
+	if spec.TargetPort != 0 {
+		allErrs = append(allErrs, validatePort(spec.TargetPort, fldPath.Child("targetPort"))...)
+	}
+	if spec.NodePort != 0 {
+		allErrs = append(allErrs, validatePort(spec.NodePort, fldPath.Child("nodePort"))...)
+		if spec.Type != string(corev1.ServiceTypeNodePort) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("nodePort"), "may only be set when type is NodePort"))
+		}
+	}
+	// The single port fields describe the first port, so that they cannot
+	// silently disagree with it.
+	if spec.Port != 0 && len(spec.Ports) > 0 {
+		first := spec.Ports[0]
+		msg := "must match ports[0] when both are set"
+		if spec.Port != first.Port {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), spec.Port, msg))
+		}
+		if spec.TargetPort != 0 && first.TargetPort != intstr.FromInt(int(spec.TargetPort)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("targetPort"), spec.TargetPort, msg))
+		}
+		if spec.NodePort != 0 && spec.NodePort != first.NodePort {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("nodePort"), spec.NodePort, msg))
+		}
+	}
+	allErrs = append(allErrs, validateServicePorts(spec.Ports, spec.Type, sidecarPortNames, fldPath.Child("ports"))...)
+	return allErrs
+}
+
+var supportedProtocols = sets.New(
+	string(corev1.ProtocolTCP),
+	string(corev1.ProtocolUDP),
+	string(corev1.ProtocolSCTP),
+)
+
+// containerPortNames returns the names of the ports of the container
+// running Workload.Image, which are those of the Service ports with a
+// numeric targetPort.
+func containerPortNames(ports []myv1beta1.ServicePort) sets.Set[string] {
+	names := sets.New[string]()
+	for _, port := range ports {
+		if port.Name != "" && port.TargetPort.Type == intstr.Int {
+			names.Insert(port.Name)
+		}
+	}
+	return names
+}
+
+// validateServicePorts validates the ports of a Service. They name the
+// container ports too, so their names are held to the rules of those.
+func validateServicePorts(ports []myv1beta1.ServicePort, serviceType string, sidecarPortNames sets.Set[string], fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	// A port may target another one by name if that one has a container
+	// port of its own, or a port of a sidecar.
+	targetPortNames := containerPortNames(ports).Union(sidecarPortNames)
+
+	type portKey struct {
+		port     int32
+		protocol corev1.Protocol
+	}
+	names, portKeys, nodePorts := sets.New[string](), sets.New[portKey](), sets.New[portKey]()
+	for i, port := range ports {
+		idxPath := fldPath.Index(i)
+
+		if port.Name == "" {
+			if len(ports) > 1 {
+				allErrs = append(allErrs, field.Required(idxPath.Child("name"), "must be set when there are several ports"))
+			}
+		} else {
+			for _, msg := range validation.IsValidPortName(port.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), port.Name, msg))
+			}
+			if names.Has(port.Name) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), port.Name))
+			}
+			names.Insert(port.Name)
+		}
+
+		allErrs = append(allErrs, validatePort(port.Port, idxPath.Child("port"))...)
+		if !supportedProtocols.Has(string(port.Protocol)) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("protocol"), port.Protocol, sets.List(supportedProtocols)))
+		}
+		key := portKey{port: port.Port, protocol: port.Protocol}
+		if portKeys.Has(key) {
+			allErrs = append(allErrs, field.Duplicate(idxPath, key))
+		}
+		portKeys.Insert(key)
+
+		allErrs = append(allErrs, validatePortNumOrName(port.TargetPort, idxPath.Child("targetPort"))...)
+		if port.TargetPort.Type == intstr.String && !targetPortNames.Has(port.TargetPort.StrVal) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("targetPort"), port.TargetPort.StrVal, "must be the name of a port with a numeric targetPort or of a port of a sidecar"))
+		}
+
+		if port.AppProtocol != nil {
+			for _, msg := range validation.IsQualifiedName(*port.AppProtocol) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("appProtocol"), *port.AppProtocol, msg))
+			}
+		}
+
+		if port.NodePort != 0 {
+			allErrs = append(allErrs, validatePort(port.NodePort, idxPath.Child("nodePort"))...)
+			if serviceType != string(corev1.ServiceTypeNodePort) {
+				allErrs = append(allErrs, field.Forbidden(idxPath.Child("nodePort"), "may only be set when type is NodePort"))
+			}
+			nodePortKey := portKey{port: port.NodePort, protocol: port.Protocol}
+			if nodePorts.Has(nodePortKey) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("nodePort"), port.NodePort))
+			}
+			nodePorts.Insert(nodePortKey)
+		}
+	}
+	return allErrs
+}
+
+func validateImage(image string, fldPath *field.Path) field.ErrorList {
+	if image == "" {
+		return field.ErrorList{field.Required(fldPath, "")}
+	}
+	match := imageReferenceRegexp.FindStringSubmatch(image)
+	if match == nil {
+		return field.ErrorList{field.Invalid(fldPath, image, "must be a valid image reference, like registry.example.com/app:v1")}
+	}
+	if len(match[1]) > maxImageNameLength {
+		return field.ErrorList{field.TooLong(fldPath, image, maxImageNameLength)}
+	}
+	return nil
+}
+
+func validatePort(port int32, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsValidPortNum(int(port)) {
+		allErrs = append(allErrs, field.Invalid(fldPath, port, msg))
+	}
+	return allErrs
+}
@@ -0,0 +1,383 @@
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// validArman returns an Arman named web that passes validation.
+func validArman() *myv1beta1.Arman {
+	return &myv1beta1.Arman{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: metav1.NamespaceDefault},
+		Spec: myv1beta1.ArmanSpec{
+			Workload: myv1beta1.WorkloadSpec{
+				Name:  "web",
+				Image: "registry.example.com/web:v1",
+			},
+			Service: myv1beta1.ServiceSpec{
+				Name: "web",
+				Type: string(corev1.ServiceTypeClusterIP),
+				Port: 80,
+			},
+		},
+	}
+}
+
+// errorFields returns the field and type of every error in errs, as
+// field: type.
+func errorFields(errs field.ErrorList) []string {
+	var got []string
+	for _, err := range errs {
+		got = append(got, fmt.Sprintf("%s: %s", err.Field, err.Type))
+	}
+	return got
+}
+
+func TestValidateArman(t *testing.T) {
+	replicas := int32(-1)
+
+	tests := []struct {
+		name   string
+		modify func(arman *myv1beta1.Arman)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(arman *myv1beta1.Arman) {},
+		},
+		{
+			name: "workload without name and image",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Name = ""
+				arman.Spec.Workload.Image = ""
+			},
+			want: []string{
+				"spec.workload.name: Required value",
+				"spec.workload.image: Required value",
+			},
+		},
+		{
+			name: "invalid image and replicas",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Image = "Registry/Web:v1"
+				arman.Spec.Workload.Replicas = &replicas
+			},
+			want: []string{
+				"spec.workload.image: Invalid value",
+				"spec.workload.replicas: Invalid value",
+			},
+		},
+		{
+			name: "neither port nor ports",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Port = 0
+			},
+			want: []string{"spec.service.ports: Required value"},
+		},
+		{
+			name: "ports without port",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Port = 0
+				arman.Spec.Service.Ports = []myv1beta1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP},
+				}
+			},
+		},
+		{
+			name: "port disagreeing with ports",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.TargetPort = 8080
+				arman.Spec.Service.Ports = []myv1beta1.ServicePort{
+					{Name: "http", Port: 8000, TargetPort: intstr.FromInt(9090), Protocol: corev1.ProtocolTCP},
+				}
+			},
+			want: []string{
+				"spec.service.port: Invalid value",
+				"spec.service.targetPort: Invalid value",
+			},
+		},
+		{
+			name: "unsupported service type",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Type = string(corev1.ServiceTypeExternalName)
+			},
+			want: []string{"spec.service.type: Unsupported value"},
+		},
+		{
+			name: "nodePort with type NodePort",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Type = string(corev1.ServiceTypeNodePort)
+				arman.Spec.Service.NodePort = 30080
+			},
+		},
+		{
+			name: "nodePort with type ClusterIP",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.NodePort = 30080
+			},
+			want: []string{"spec.service.nodePort: Forbidden"},
+		},
+		{
+			name: "nodePort of a port with type LoadBalancer",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Port = 0
+				arman.Spec.Service.Type = string(corev1.ServiceTypeLoadBalancer)
+				arman.Spec.Service.Ports = []myv1beta1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP},
+					{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090), Protocol: corev1.ProtocolTCP, NodePort: 30090},
+				}
+			},
+			want: []string{"spec.service.ports[1].nodePort: Forbidden"},
+		},
+		{
+			name: "ports without names, protocols and with duplicates",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Port = 0
+				arman.Spec.Service.Ports = []myv1beta1.ServicePort{
+					{Port: 80, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(8080)},
+					{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(8080)},
+					{Name: "http", Port: 443, TargetPort: intstr.FromInt(8443)},
+				}
+			},
+			want: []string{
+				"spec.service.ports[0].name: Required value",
+				"spec.service.ports[1]: Duplicate value",
+				"spec.service.ports[2].name: Duplicate value",
+				"spec.service.ports[2].protocol: Unsupported value",
+			},
+		},
+		{
+			name: "targetPort naming an unknown port",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Port = 0
+				arman.Spec.Service.Ports = []myv1beta1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromString("web"), Protocol: corev1.ProtocolTCP},
+				}
+			},
+			want: []string{"spec.service.ports[0].targetPort: Invalid value"},
+		},
+		{
+			name: "targetPort naming a port of a sidecar",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Port = 0
+				arman.Spec.Service.Ports = []myv1beta1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromString("proxy"), Protocol: corev1.ProtocolTCP},
+				}
+				arman.Spec.Workload.Sidecars = []corev1.Container{{
+					Name:  "proxy",
+					Image: "envoyproxy/envoy:v1.27.0",
+					Ports: []corev1.ContainerPort{{Name: "proxy", ContainerPort: 8443}},
+				}}
+			},
+		},
+		{
+			name: "env with duplicate and conflicting values",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Env = []corev1.EnvVar{
+					{Name: "MODE", Value: "prod"},
+					{Name: "MODE", Value: "dev"},
+					{Name: "TOKEN", Value: "x", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "token"}}},
+				}
+			},
+			want: []string{
+				"spec.workload.env[1].name: Duplicate value",
+				"spec.workload.env[2].valueFrom.secretKeyRef.name: Required value",
+				"spec.workload.env[2].valueFrom: Invalid value",
+			},
+		},
+		{
+			name: "config mount on a reserved volume",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.ConfigMounts = []myv1beta1.ConfigMount{{
+					Name:      myv1beta1.ConfigFilesVolumeName,
+					MountPath: "etc/web",
+				}}
+			},
+			want: []string{
+				"spec.workload.configMounts[0].name: Invalid value",
+				"spec.workload.configMounts[0].mountPath: Invalid value",
+				"spec.workload.configMounts[0]: Invalid value",
+			},
+		},
+		{
+			name: "liveness probe without handler and with several successes",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.LivenessProbe = &corev1.Probe{SuccessThreshold: 2}
+			},
+			want: []string{
+				"spec.workload.livenessProbe: Invalid value",
+				"spec.workload.livenessProbe.successThreshold: Invalid value",
+			},
+		},
+		{
+			name: "sidecar named like the main container",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Sidecars = []corev1.Container{{Name: myv1beta1.ContainerName, Image: "busybox"}}
+			},
+			want: []string{"spec.workload.sidecars[0].name: Duplicate value"},
+		},
+		{
+			name: "probed init container",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.InitContainers = []corev1.Container{{
+					Name:           "migrate",
+					Image:          "busybox",
+					ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(80)}}},
+				}}
+			},
+			want: []string{"spec.workload.initContainers[0].readinessProbe: Forbidden"},
+		},
+		{
+			name: "volume mount of an unknown volume",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Volumes = []corev1.Volume{{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+				arman.Spec.Workload.VolumeMounts = []corev1.VolumeMount{
+					{Name: "cache", MountPath: "/cache"},
+					{Name: "data", MountPath: myv1beta1.ConfigFilesMountPath},
+				}
+			},
+			want: []string{
+				"spec.workload.volumeMounts[1].name: Not found",
+				"spec.workload.volumeMounts[1].mountPath: Duplicate value",
+			},
+		},
+		{
+			name: "files with invalid names",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.ConfigFiles = map[string]string{"app.yaml": "debug: true"}
+				arman.Spec.SecretFiles = map[string]string{"api token": "x"}
+			},
+			want: []string{"spec.secretFiles[api token]: Invalid value"},
+		},
+		{
+			name: "unsupported policies",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.DeletionPolicy = "Keep"
+				arman.Spec.RenamePolicy = "Sometimes"
+			},
+			want: []string{
+				"spec.deletionPolicy: Unsupported value",
+				"spec.renamePolicy: Unsupported value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arman := validArman()
+			tt.modify(arman)
+			got := errorFields(ValidateArman(arman))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ValidateArman() errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateArmanUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  myv1beta1.RenamePolicy
+		invalid bool
+		modify  func(arman *myv1beta1.Arman)
+		want    []string
+	}{
+		{
+			name:    "unchanged spec of an invalid Arman",
+			policy:  myv1beta1.RenamePolicyForbid,
+			invalid: true,
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Finalizers = []string{myv1beta1.CleanupFinalizer}
+			},
+		},
+		{
+			name:   "image changed",
+			policy: myv1beta1.RenamePolicyForbid,
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Image = "registry.example.com/web:v2"
+			},
+		},
+		{
+			name:   "renamed under Forbid",
+			policy: myv1beta1.RenamePolicyForbid,
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Name = "web-v2"
+				arman.Spec.Service.Name = "web-v2"
+			},
+			want: []string{
+				"spec.workload.name: Invalid value",
+				"spec.service.name: Invalid value",
+			},
+		},
+		{
+			name: "renamed under the defaulted policy",
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Service.Name = "web-v2"
+			},
+			want: []string{"spec.service.name: Invalid value"},
+		},
+		{
+			name:   "renamed while allowing renames",
+			policy: myv1beta1.RenamePolicyForbid,
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.RenamePolicy = myv1beta1.RenamePolicyAllow
+				arman.Spec.Workload.Name = "web-v2"
+			},
+			want: []string{"spec.workload.name: Invalid value"},
+		},
+		{
+			name:   "renamed under Allow",
+			policy: myv1beta1.RenamePolicyAllow,
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Name = "web-v2"
+				arman.Spec.Service.Name = "web-v2"
+			},
+		},
+		{
+			name:   "renamed invalidly under Allow",
+			policy: myv1beta1.RenamePolicyAllow,
+			modify: func(arman *myv1beta1.Arman) {
+				arman.Spec.Workload.Name = "Web"
+			},
+			want: []string{"spec.workload.name: Invalid value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldArman := validArman()
+			oldArman.Spec.RenamePolicy = tt.policy
+			if tt.invalid {
+				oldArman.Spec.Workload.Image = ""
+			}
+			arman := oldArman.DeepCopy()
+			tt.modify(arman)
+			got := errorFields(ValidateArmanUpdate(arman, oldArman))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ValidateArmanUpdate() errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestValidateArmanUpdateDefaultedName checks that setting a name to the one
+// it defaults to is not a rename.
+func TestValidateArmanUpdateDefaultedName(t *testing.T) {
+	oldArman := validArman()
+	oldArman.Spec.Service.Name = ""
+	arman := oldArman.DeepCopy()
+	arman.Spec.Service.Name = oldArman.Name
+
+	got := errorFields(ValidateArmanUpdate(arman, oldArman))
+	if len(got) != 0 {
+		t.Errorf("ValidateArmanUpdate() = %v, want no errors", got)
+	}
+}
@@ -18,16 +18,23 @@ limitations under the License.
 
 package v1alpha1
 
+import (
+	v1alpha1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1alpha1"
+)
+
 // ArmanSpecApplyConfiguration represents an declarative configuration of the ArmanSpec type for use
 // with apply.
 type ArmanSpecApplyConfiguration struct {
-	DeploymentName    *string `json:"deploymentName,omitempty"`
-	DeploymentImage   *string `json:"deploymentImage,omitempty"`
-	Replicas          *int32  `json:"replicas,omitempty"`
-	ServiceName       *string `json:"serviceName,omitempty"`
-	ServicePort       *int32  `json:"servicePort,omitempty"`
-	ServiceType       *string `json:"serviceType,omitempty"`
-	ServiceTargetPort *int32  `json:"serviceTargetPort,omitempty"`
+	DeploymentName    *string                  `json:"deploymentName,omitempty"`
+	DeploymentImage   *string                  `json:"deploymentImage,omitempty"`
+	Replicas          *int32                   `json:"replicas,omitempty"`
+	ServiceName       *string                  `json:"serviceName,omitempty"`
+	ServicePort       *int32                   `json:"servicePort,omitempty"`
+	ServiceType       *string                  `json:"serviceType,omitempty"`
+	ServiceTargetPort *int32                   `json:"serviceTargetPort,omitempty"`
+	NodePort          *int32                   `json:"nodePort,omitempty"`
+	DeletionPolicy    *v1alpha1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+	RenamePolicy      *v1alpha1.RenamePolicy   `json:"renamePolicy,omitempty"`
 }
 
 // ArmanSpecApplyConfiguration constructs an declarative configuration of the ArmanSpec type for use with
@@ -91,3 +98,27 @@ func (b *ArmanSpecApplyConfiguration) WithServiceTargetPort(value int32) *ArmanS
 	b.ServiceTargetPort = &value
 	return b
 }
+
+// WithNodePort sets the NodePort field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the NodePort field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithNodePort(value int32) *ArmanSpecApplyConfiguration {
+	b.NodePort = &value
+	return b
+}
+
+// WithDeletionPolicy sets the DeletionPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the DeletionPolicy field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithDeletionPolicy(value v1alpha1.DeletionPolicy) *ArmanSpecApplyConfiguration {
+	b.DeletionPolicy = &value
+	return b
+}
+
+// WithRenamePolicy sets the RenamePolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RenamePolicy field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithRenamePolicy(value v1alpha1.RenamePolicy) *ArmanSpecApplyConfiguration {
+	b.RenamePolicy = &value
+	return b
+}
@@ -18,10 +18,23 @@ limitations under the License.
 
 package v1alpha1
 
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // ArmanStatusApplyConfiguration represents an declarative configuration of the ArmanStatus type for use
 // with apply.
 type ArmanStatusApplyConfiguration struct {
-	AvailableReplicas *int32 `json:"availableReplicas,omitempty"`
+	ObservedGeneration *int64         `json:"observedGeneration,omitempty"`
+	Conditions         []v1.Condition `json:"conditions,omitempty"`
+	DeploymentName     *string        `json:"deploymentName,omitempty"`
+	ServiceName        *string        `json:"serviceName,omitempty"`
+	Endpoint           *string        `json:"endpoint,omitempty"`
+	Replicas           *int32         `json:"replicas,omitempty"`
+	Selector           *string        `json:"selector,omitempty"`
+	AvailableReplicas  *int32         `json:"availableReplicas,omitempty"`
+	ReadyReplicas      *int32         `json:"readyReplicas,omitempty"`
+	UpdatedReplicas    *int32         `json:"updatedReplicas,omitempty"`
 }
 
 // ArmanStatusApplyConfiguration constructs an declarative configuration of the ArmanStatus type for use with
@@ -30,6 +43,64 @@ func ArmanStatus() *ArmanStatusApplyConfiguration {
 	return &ArmanStatusApplyConfiguration{}
 }
 
+// WithObservedGeneration sets the ObservedGeneration field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ObservedGeneration field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithObservedGeneration(value int64) *ArmanStatusApplyConfiguration {
+	b.ObservedGeneration = &value
+	return b
+}
+
+// WithConditions adds the given value to the Conditions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Conditions field.
+func (b *ArmanStatusApplyConfiguration) WithConditions(values ...v1.Condition) *ArmanStatusApplyConfiguration {
+	for i := range values {
+		b.Conditions = append(b.Conditions, values[i])
+	}
+	return b
+}
+
+// WithDeploymentName sets the DeploymentName field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the DeploymentName field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithDeploymentName(value string) *ArmanStatusApplyConfiguration {
+	b.DeploymentName = &value
+	return b
+}
+
+// WithServiceName sets the ServiceName field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ServiceName field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithServiceName(value string) *ArmanStatusApplyConfiguration {
+	b.ServiceName = &value
+	return b
+}
+
+// WithEndpoint sets the Endpoint field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Endpoint field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithEndpoint(value string) *ArmanStatusApplyConfiguration {
+	b.Endpoint = &value
+	return b
+}
+
+// WithReplicas sets the Replicas field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Replicas field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithReplicas(value int32) *ArmanStatusApplyConfiguration {
+	b.Replicas = &value
+	return b
+}
+
+// WithSelector sets the Selector field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Selector field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithSelector(value string) *ArmanStatusApplyConfiguration {
+	b.Selector = &value
+	return b
+}
+
 // WithAvailableReplicas sets the AvailableReplicas field in the declarative configuration to the given value
 // and returns the receiver, so that objects can be built by chaining "With" function invocations.
 // If called multiple times, the AvailableReplicas field is set to the value of the last call.
@@ -37,3 +108,19 @@ func (b *ArmanStatusApplyConfiguration) WithAvailableReplicas(value int32) *Arma
 	b.AvailableReplicas = &value
 	return b
 }
+
+// WithReadyReplicas sets the ReadyReplicas field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ReadyReplicas field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithReadyReplicas(value int32) *ArmanStatusApplyConfiguration {
+	b.ReadyReplicas = &value
+	return b
+}
+
+// WithUpdatedReplicas sets the UpdatedReplicas field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the UpdatedReplicas field is set to the value of the last call.
+func (b *ArmanStatusApplyConfiguration) WithUpdatedReplicas(value int32) *ArmanStatusApplyConfiguration {
+	b.UpdatedReplicas = &value
+	return b
+}
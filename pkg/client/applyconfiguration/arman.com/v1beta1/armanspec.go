@@ -0,0 +1,109 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	armancomv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// ArmanSpecApplyConfiguration represents an declarative configuration of the ArmanSpec type for use
+// with apply.
+type ArmanSpecApplyConfiguration struct {
+	Workload                   *WorkloadSpecApplyConfiguration `json:"workload,omitempty"`
+	Service                    *ServiceSpecApplyConfiguration  `json:"service,omitempty"`
+	DeletionPolicy             *armancomv1beta1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+	RenamePolicy               *armancomv1beta1.RenamePolicy   `json:"renamePolicy,omitempty"`
+	ConfigFiles                map[string]string               `json:"configFiles,omitempty"`
+	SecretFiles                map[string]string               `json:"secretFiles,omitempty"`
+	ConfigRevisionHistoryLimit *int32                          `json:"configRevisionHistoryLimit,omitempty"`
+}
+
+// ArmanSpecApplyConfiguration constructs an declarative configuration of the ArmanSpec type for use with
+// apply.
+func ArmanSpec() *ArmanSpecApplyConfiguration {
+	return &ArmanSpecApplyConfiguration{}
+}
+
+// WithWorkload sets the Workload field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Workload field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithWorkload(value *WorkloadSpecApplyConfiguration) *ArmanSpecApplyConfiguration {
+	b.Workload = value
+	return b
+}
+
+// WithService sets the Service field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Service field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithService(value *ServiceSpecApplyConfiguration) *ArmanSpecApplyConfiguration {
+	b.Service = value
+	return b
+}
+
+// WithDeletionPolicy sets the DeletionPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the DeletionPolicy field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithDeletionPolicy(value armancomv1beta1.DeletionPolicy) *ArmanSpecApplyConfiguration {
+	b.DeletionPolicy = &value
+	return b
+}
+
+// WithRenamePolicy sets the RenamePolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RenamePolicy field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithRenamePolicy(value armancomv1beta1.RenamePolicy) *ArmanSpecApplyConfiguration {
+	b.RenamePolicy = &value
+	return b
+}
+
+// WithConfigFiles puts the entries into the ConfigFiles field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, the entries provided by each call will be put on the ConfigFiles field,
+// overwriting an existing map entries in ConfigFiles field with the same key.
+func (b *ArmanSpecApplyConfiguration) WithConfigFiles(entries map[string]string) *ArmanSpecApplyConfiguration {
+	if b.ConfigFiles == nil && len(entries) > 0 {
+		b.ConfigFiles = make(map[string]string, len(entries))
+	}
+	for k, v := range entries {
+		b.ConfigFiles[k] = v
+	}
+	return b
+}
+
+// WithSecretFiles puts the entries into the SecretFiles field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, the entries provided by each call will be put on the SecretFiles field,
+// overwriting an existing map entries in SecretFiles field with the same key.
+func (b *ArmanSpecApplyConfiguration) WithSecretFiles(entries map[string]string) *ArmanSpecApplyConfiguration {
+	if b.SecretFiles == nil && len(entries) > 0 {
+		b.SecretFiles = make(map[string]string, len(entries))
+	}
+	for k, v := range entries {
+		b.SecretFiles[k] = v
+	}
+	return b
+}
+
+// WithConfigRevisionHistoryLimit sets the ConfigRevisionHistoryLimit field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ConfigRevisionHistoryLimit field is set to the value of the last call.
+func (b *ArmanSpecApplyConfiguration) WithConfigRevisionHistoryLimit(value int32) *ArmanSpecApplyConfiguration {
+	b.ConfigRevisionHistoryLimit = &value
+	return b
+}
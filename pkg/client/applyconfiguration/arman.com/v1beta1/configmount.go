@@ -0,0 +1,70 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// ConfigMountApplyConfiguration represents an declarative configuration of the ConfigMount type for use
+// with apply.
+type ConfigMountApplyConfiguration struct {
+	Name      *string                   `json:"name,omitempty"`
+	MountPath *string                   `json:"mountPath,omitempty"`
+	ConfigMap *v1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+	Secret    *v1.SecretVolumeSource    `json:"secret,omitempty"`
+}
+
+// ConfigMountApplyConfiguration constructs an declarative configuration of the ConfigMount type for use with
+// apply.
+func ConfigMount() *ConfigMountApplyConfiguration {
+	return &ConfigMountApplyConfiguration{}
+}
+
+// WithName sets the Name field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Name field is set to the value of the last call.
+func (b *ConfigMountApplyConfiguration) WithName(value string) *ConfigMountApplyConfiguration {
+	b.Name = &value
+	return b
+}
+
+// WithMountPath sets the MountPath field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the MountPath field is set to the value of the last call.
+func (b *ConfigMountApplyConfiguration) WithMountPath(value string) *ConfigMountApplyConfiguration {
+	b.MountPath = &value
+	return b
+}
+
+// WithConfigMap sets the ConfigMap field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ConfigMap field is set to the value of the last call.
+func (b *ConfigMountApplyConfiguration) WithConfigMap(value v1.ConfigMapVolumeSource) *ConfigMountApplyConfiguration {
+	b.ConfigMap = &value
+	return b
+}
+
+// WithSecret sets the Secret field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Secret field is set to the value of the last call.
+func (b *ConfigMountApplyConfiguration) WithSecret(value v1.SecretVolumeSource) *ConfigMountApplyConfiguration {
+	b.Secret = &value
+	return b
+}
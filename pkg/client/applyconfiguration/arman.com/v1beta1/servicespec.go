@@ -0,0 +1,89 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+// ServiceSpecApplyConfiguration represents an declarative configuration of the ServiceSpec type for use
+// with apply.
+type ServiceSpecApplyConfiguration struct {
+	Name       *string                         `json:"name,omitempty"`
+	Type       *string                         `json:"type,omitempty"`
+	Ports      []ServicePortApplyConfiguration `json:"ports,omitempty"`
+	Port       *int32                          `json:"port,omitempty"`
+	TargetPort *int32                          `json:"targetPort,omitempty"`
+	NodePort   *int32                          `json:"nodePort,omitempty"`
+}
+
+// ServiceSpecApplyConfiguration constructs an declarative configuration of the ServiceSpec type for use with
+// apply.
+func ServiceSpec() *ServiceSpecApplyConfiguration {
+	return &ServiceSpecApplyConfiguration{}
+}
+
+// WithName sets the Name field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Name field is set to the value of the last call.
+func (b *ServiceSpecApplyConfiguration) WithName(value string) *ServiceSpecApplyConfiguration {
+	b.Name = &value
+	return b
+}
+
+// WithType sets the Type field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Type field is set to the value of the last call.
+func (b *ServiceSpecApplyConfiguration) WithType(value string) *ServiceSpecApplyConfiguration {
+	b.Type = &value
+	return b
+}
+
+// WithPorts adds the given value to the Ports field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Ports field.
+func (b *ServiceSpecApplyConfiguration) WithPorts(values ...*ServicePortApplyConfiguration) *ServiceSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithPorts")
+		}
+		b.Ports = append(b.Ports, *values[i])
+	}
+	return b
+}
+
+// WithPort sets the Port field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Port field is set to the value of the last call.
+func (b *ServiceSpecApplyConfiguration) WithPort(value int32) *ServiceSpecApplyConfiguration {
+	b.Port = &value
+	return b
+}
+
+// WithTargetPort sets the TargetPort field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TargetPort field is set to the value of the last call.
+func (b *ServiceSpecApplyConfiguration) WithTargetPort(value int32) *ServiceSpecApplyConfiguration {
+	b.TargetPort = &value
+	return b
+}
+
+// WithNodePort sets the NodePort field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the NodePort field is set to the value of the last call.
+func (b *ServiceSpecApplyConfiguration) WithNodePort(value int32) *ServiceSpecApplyConfiguration {
+	b.NodePort = &value
+	return b
+}
@@ -0,0 +1,186 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// WorkloadSpecApplyConfiguration represents an declarative configuration of the WorkloadSpec type for use
+// with apply.
+type WorkloadSpecApplyConfiguration struct {
+	Name           *string                         `json:"name,omitempty"`
+	Image          *string                         `json:"image,omitempty"`
+	Replicas       *int32                          `json:"replicas,omitempty"`
+	Env            []v1.EnvVar                     `json:"env,omitempty"`
+	EnvFrom        []v1.EnvFromSource              `json:"envFrom,omitempty"`
+	ConfigMounts   []ConfigMountApplyConfiguration `json:"configMounts,omitempty"`
+	Resources      *v1.ResourceRequirements        `json:"resources,omitempty"`
+	LivenessProbe  *v1.Probe                       `json:"livenessProbe,omitempty"`
+	ReadinessProbe *v1.Probe                       `json:"readinessProbe,omitempty"`
+	StartupProbe   *v1.Probe                       `json:"startupProbe,omitempty"`
+	Lifecycle      *v1.Lifecycle                   `json:"lifecycle,omitempty"`
+	VolumeMounts   []v1.VolumeMount                `json:"volumeMounts,omitempty"`
+	InitContainers []v1.Container                  `json:"initContainers,omitempty"`
+	Sidecars       []v1.Container                  `json:"sidecars,omitempty"`
+	Volumes        []v1.Volume                     `json:"volumes,omitempty"`
+}
+
+// WorkloadSpecApplyConfiguration constructs an declarative configuration of the WorkloadSpec type for use with
+// apply.
+func WorkloadSpec() *WorkloadSpecApplyConfiguration {
+	return &WorkloadSpecApplyConfiguration{}
+}
+
+// WithName sets the Name field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Name field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithName(value string) *WorkloadSpecApplyConfiguration {
+	b.Name = &value
+	return b
+}
+
+// WithImage sets the Image field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Image field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithImage(value string) *WorkloadSpecApplyConfiguration {
+	b.Image = &value
+	return b
+}
+
+// WithReplicas sets the Replicas field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Replicas field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithReplicas(value int32) *WorkloadSpecApplyConfiguration {
+	b.Replicas = &value
+	return b
+}
+
+// WithEnv adds the given value to the Env field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Env field.
+func (b *WorkloadSpecApplyConfiguration) WithEnv(values ...v1.EnvVar) *WorkloadSpecApplyConfiguration {
+	for i := range values {
+		b.Env = append(b.Env, values[i])
+	}
+	return b
+}
+
+// WithEnvFrom adds the given value to the EnvFrom field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the EnvFrom field.
+func (b *WorkloadSpecApplyConfiguration) WithEnvFrom(values ...v1.EnvFromSource) *WorkloadSpecApplyConfiguration {
+	for i := range values {
+		b.EnvFrom = append(b.EnvFrom, values[i])
+	}
+	return b
+}
+
+// WithConfigMounts adds the given value to the ConfigMounts field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the ConfigMounts field.
+func (b *WorkloadSpecApplyConfiguration) WithConfigMounts(values ...*ConfigMountApplyConfiguration) *WorkloadSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithConfigMounts")
+		}
+		b.ConfigMounts = append(b.ConfigMounts, *values[i])
+	}
+	return b
+}
+
+// WithResources sets the Resources field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Resources field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithResources(value v1.ResourceRequirements) *WorkloadSpecApplyConfiguration {
+	b.Resources = &value
+	return b
+}
+
+// WithLivenessProbe sets the LivenessProbe field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the LivenessProbe field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithLivenessProbe(value v1.Probe) *WorkloadSpecApplyConfiguration {
+	b.LivenessProbe = &value
+	return b
+}
+
+// WithReadinessProbe sets the ReadinessProbe field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ReadinessProbe field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithReadinessProbe(value v1.Probe) *WorkloadSpecApplyConfiguration {
+	b.ReadinessProbe = &value
+	return b
+}
+
+// WithStartupProbe sets the StartupProbe field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the StartupProbe field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithStartupProbe(value v1.Probe) *WorkloadSpecApplyConfiguration {
+	b.StartupProbe = &value
+	return b
+}
+
+// WithLifecycle sets the Lifecycle field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Lifecycle field is set to the value of the last call.
+func (b *WorkloadSpecApplyConfiguration) WithLifecycle(value v1.Lifecycle) *WorkloadSpecApplyConfiguration {
+	b.Lifecycle = &value
+	return b
+}
+
+// WithVolumeMounts adds the given value to the VolumeMounts field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the VolumeMounts field.
+func (b *WorkloadSpecApplyConfiguration) WithVolumeMounts(values ...v1.VolumeMount) *WorkloadSpecApplyConfiguration {
+	for i := range values {
+		b.VolumeMounts = append(b.VolumeMounts, values[i])
+	}
+	return b
+}
+
+// WithInitContainers adds the given value to the InitContainers field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the InitContainers field.
+func (b *WorkloadSpecApplyConfiguration) WithInitContainers(values ...v1.Container) *WorkloadSpecApplyConfiguration {
+	for i := range values {
+		b.InitContainers = append(b.InitContainers, values[i])
+	}
+	return b
+}
+
+// WithSidecars adds the given value to the Sidecars field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Sidecars field.
+func (b *WorkloadSpecApplyConfiguration) WithSidecars(values ...v1.Container) *WorkloadSpecApplyConfiguration {
+	for i := range values {
+		b.Sidecars = append(b.Sidecars, values[i])
+	}
+	return b
+}
+
+// WithVolumes adds the given value to the Volumes field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Volumes field.
+func (b *WorkloadSpecApplyConfiguration) WithVolumes(values ...v1.Volume) *WorkloadSpecApplyConfiguration {
+	for i := range values {
+		b.Volumes = append(b.Volumes, values[i])
+	}
+	return b
+}
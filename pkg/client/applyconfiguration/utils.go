@@ -20,7 +20,9 @@ package applyconfiguration
 
 import (
 	v1alpha1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1alpha1"
+	v1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
 	armancomv1alpha1 "github.com/sheikh-arman/crd-controller/pkg/client/applyconfiguration/arman.com/v1alpha1"
+	armancomv1beta1 "github.com/sheikh-arman/crd-controller/pkg/client/applyconfiguration/arman.com/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -36,6 +38,22 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 	case v1alpha1.SchemeGroupVersion.WithKind("ArmanStatus"):
 		return &armancomv1alpha1.ArmanStatusApplyConfiguration{}
 
+		// Group=arman.com, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithKind("Arman"):
+		return &armancomv1beta1.ArmanApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("ArmanSpec"):
+		return &armancomv1beta1.ArmanSpecApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("ArmanStatus"):
+		return &armancomv1beta1.ArmanStatusApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("ConfigMount"):
+		return &armancomv1beta1.ConfigMountApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("ServicePort"):
+		return &armancomv1beta1.ServicePortApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("ServiceSpec"):
+		return &armancomv1beta1.ServiceSpecApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("WorkloadSpec"):
+		return &armancomv1beta1.WorkloadSpecApplyConfiguration{}
+
 	}
 	return nil
 }
@@ -23,6 +23,7 @@ import (
 	"net/http"
 
 	armanv1alpha1 "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/typed/arman.com/v1alpha1"
+	armanv1beta1 "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/typed/arman.com/v1beta1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -31,12 +32,14 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	ArmanV1alpha1() armanv1alpha1.ArmanV1alpha1Interface
+	ArmanV1beta1() armanv1beta1.ArmanV1beta1Interface
 }
 
 // Clientset contains the clients for groups.
 type Clientset struct {
 	*discovery.DiscoveryClient
 	armanV1alpha1 *armanv1alpha1.ArmanV1alpha1Client
+	armanV1beta1  *armanv1beta1.ArmanV1beta1Client
 }
 
 // ArmanV1alpha1 retrieves the ArmanV1alpha1Client
@@ -44,6 +47,11 @@ func (c *Clientset) ArmanV1alpha1() armanv1alpha1.ArmanV1alpha1Interface {
 	return c.armanV1alpha1
 }
 
+// ArmanV1beta1 retrieves the ArmanV1beta1Client
+func (c *Clientset) ArmanV1beta1() armanv1beta1.ArmanV1beta1Interface {
+	return c.armanV1beta1
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -92,6 +100,10 @@ func NewForConfigAndClient(c *rest.Config, httpClient *http.Client) (*Clientset,
 	if err != nil {
 		return nil, err
 	}
+	cs.armanV1beta1, err = armanv1beta1.NewForConfigAndClient(&configShallowCopy, httpClient)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfigAndClient(&configShallowCopy, httpClient)
 	if err != nil {
@@ -114,6 +126,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.armanV1alpha1 = armanv1alpha1.New(c)
+	cs.armanV1beta1 = armanv1beta1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
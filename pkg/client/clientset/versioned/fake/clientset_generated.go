@@ -22,6 +22,8 @@ import (
 	clientset "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned"
 	armanv1alpha1 "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/typed/arman.com/v1alpha1"
 	fakearmanv1alpha1 "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/typed/arman.com/v1alpha1/fake"
+	armanv1beta1 "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/typed/arman.com/v1beta1"
+	fakearmanv1beta1 "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/typed/arman.com/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -83,3 +85,8 @@ var (
 func (c *Clientset) ArmanV1alpha1() armanv1alpha1.ArmanV1alpha1Interface {
 	return &fakearmanv1alpha1.FakeArmanV1alpha1{Fake: &c.Fake}
 }
+
+// ArmanV1beta1 retrieves the ArmanV1beta1Client
+func (c *Clientset) ArmanV1beta1() armanv1beta1.ArmanV1beta1Interface {
+	return &fakearmanv1beta1.FakeArmanV1beta1{Fake: &c.Fake}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+	json "encoding/json"
+	"fmt"
+
+	v1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	armancomv1beta1 "github.com/sheikh-arman/crd-controller/pkg/client/applyconfiguration/arman.com/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeArmans implements ArmanInterface
+type FakeArmans struct {
+	Fake *FakeArmanV1beta1
+	ns   string
+}
+
+var armansResource = v1beta1.SchemeGroupVersion.WithResource("armans")
+
+var armansKind = v1beta1.SchemeGroupVersion.WithKind("Arman")
+
+// Get takes name of the arman, and returns the corresponding arman object, and an error if there is any.
+func (c *FakeArmans) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.Arman, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(armansResource, c.ns, name), &v1beta1.Arman{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Arman), err
+}
+
+// List takes label and field selectors, and returns the list of Armans that match those selectors.
+func (c *FakeArmans) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.ArmanList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(armansResource, armansKind, c.ns, opts), &v1beta1.ArmanList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.ArmanList{ListMeta: obj.(*v1beta1.ArmanList).ListMeta}
+	for _, item := range obj.(*v1beta1.ArmanList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested armans.
+func (c *FakeArmans) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(armansResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a arman and creates it.  Returns the server's representation of the arman, and an error, if there is any.
+func (c *FakeArmans) Create(ctx context.Context, arman *v1beta1.Arman, opts v1.CreateOptions) (result *v1beta1.Arman, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(armansResource, c.ns, arman), &v1beta1.Arman{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Arman), err
+}
+
+// Update takes the representation of a arman and updates it. Returns the server's representation of the arman, and an error, if there is any.
+func (c *FakeArmans) Update(ctx context.Context, arman *v1beta1.Arman, opts v1.UpdateOptions) (result *v1beta1.Arman, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(armansResource, c.ns, arman), &v1beta1.Arman{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Arman), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeArmans) UpdateStatus(ctx context.Context, arman *v1beta1.Arman, opts v1.UpdateOptions) (*v1beta1.Arman, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(armansResource, "status", c.ns, arman), &v1beta1.Arman{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Arman), err
+}
+
+// Delete takes name of the arman and deletes it. Returns an error if one occurs.
+func (c *FakeArmans) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(armansResource, c.ns, name, opts), &v1beta1.Arman{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeArmans) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(armansResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.ArmanList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched arman.
+func (c *FakeArmans) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.Arman, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(armansResource, c.ns, name, pt, data, subresources...), &v1beta1.Arman{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Arman), err
+}
+
+// Apply takes the given apply declarative configuration, applies it and returns the applied arman.
+func (c *FakeArmans) Apply(ctx context.Context, arman *armancomv1beta1.ArmanApplyConfiguration, opts v1.ApplyOptions) (result *v1beta1.Arman, err error) {
+	if arman == nil {
+		return nil, fmt.Errorf("arman provided to Apply must not be nil")
+	}
+	data, err := json.Marshal(arman)
+	if err != nil {
+		return nil, err
+	}
+	name := arman.Name
+	if name == nil {
+		return nil, fmt.Errorf("arman.Name must be provided to Apply")
+	}
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(armansResource, c.ns, *name, types.ApplyPatchType, data), &v1beta1.Arman{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Arman), err
+}
+
+// ApplyStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+func (c *FakeArmans) ApplyStatus(ctx context.Context, arman *armancomv1beta1.ArmanApplyConfiguration, opts v1.ApplyOptions) (result *v1beta1.Arman, err error) {
+	if arman == nil {
+		return nil, fmt.Errorf("arman provided to Apply must not be nil")
+	}
+	data, err := json.Marshal(arman)
+	if err != nil {
+		return nil, err
+	}
+	name := arman.Name
+	if name == nil {
+		return nil, fmt.Errorf("arman.Name must be provided to Apply")
+	}
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(armansResource, c.ns, *name, types.ApplyPatchType, data, "status"), &v1beta1.Arman{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Arman), err
+}
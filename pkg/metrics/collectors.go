@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	mylisters "github.com/sheikh-arman/crd-controller/pkg/client/listers/arman.com/v1beta1"
+)
+
+var (
+	armansDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "armans"),
+		"Number of Armans in the informer cache by namespace.",
+		[]string{"namespace"}, nil)
+
+	informerSyncedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "informer_synced"),
+		"Whether the informer cache has synced (1) or not (0).",
+		[]string{"informer"}, nil)
+)
+
+// armanCollector counts the Armans of the informer cache when scraped, so
+// the counts can never drift from the cache.
+type armanCollector struct {
+	lister mylisters.ArmanLister
+}
+
+// NewArmanCollector returns a collector of the number of Armans per
+// namespace listed by lister.
+func NewArmanCollector(lister mylisters.ArmanLister) prometheus.Collector {
+	return armanCollector{lister: lister}
+}
+
+func (c armanCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- armansDesc
+}
+
+func (c armanCollector) Collect(ch chan<- prometheus.Metric) {
+	armans, err := c.lister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Error listing armans for metrics: %s", err.Error())
+		return
+	}
+	counts := map[string]int{}
+	for _, arman := range armans {
+		counts[arman.Namespace]++
+	}
+	for ns, count := range counts {
+		ch <- prometheus.MustNewConstMetric(armansDesc, prometheus.GaugeValue, float64(count), ns)
+	}
+}
+
+// informerSyncedCollector reports whether each informer has synced.
+type informerSyncedCollector struct {
+	names  []string
+	synced map[string]cache.InformerSynced
+}
+
+// NewInformerSyncedCollector returns a collector of the sync status of the
+// informers, keyed by the name they are reported under.
+func NewInformerSyncedCollector(synced map[string]cache.InformerSynced) prometheus.Collector {
+	names := make([]string, 0, len(synced))
+	for name := range synced {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return informerSyncedCollector{names: names, synced: synced}
+}
+
+func (c informerSyncedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- informerSyncedDesc
+}
+
+func (c informerSyncedCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, name := range c.names {
+		value := 0.0
+		if c.synced[name]() {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(informerSyncedDesc, prometheus.GaugeValue, value, name)
+	}
+}
@@ -0,0 +1,68 @@
+// Package metrics holds the Prometheus metrics of the controller and the
+// registry they are served from.
+//
+// Call RegisterWorkqueueProvider before creating the workqueues so they report
+// their depth, adds, latency, work duration and retries.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "arman_controller"
+
+// Results of a reconcile.
+const (
+	ResultSuccess = "success"
+	ResultRequeue = "requeue"
+	ResultError   = "error"
+)
+
+var (
+	// Registry holds every metric of the controller, along with the Go
+	// runtime and process metrics.
+	Registry = prometheus.NewRegistry()
+
+	// ReconcileTotal counts the reconciles of Armans by result.
+	ReconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_total",
+		Help:      "Total number of Arman reconciles by result.",
+	}, []string{"result"})
+
+	// ReconcileDuration observes how long reconciles of Armans take by
+	// result.
+	ReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "How long in seconds reconciling an Arman takes by result.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	}, []string{"result"})
+
+	// ChildRequestsTotal counts the API calls made for the children of
+	// Armans by kind of child, verb and result.
+	ChildRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "child_requests_total",
+		Help:      "Total number of API calls creating, updating or deleting children of Armans by kind, verb and result.",
+	}, []string{"kind", "verb", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ReconcileTotal,
+		ReconcileDuration,
+		ChildRequestsTotal,
+	)
+}
+
+// Handler serves the metrics of Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
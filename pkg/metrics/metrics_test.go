@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	mylisters "github.com/sheikh-arman/crd-controller/pkg/client/listers/arman.com/v1beta1"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, collector := range []prometheus.Collector{ReconcileTotal, ReconcileDuration, ChildRequestsTotal} {
+		var registered prometheus.AlreadyRegisteredError
+		if err := Registry.Register(collector); !errors.As(err, &registered) {
+			t.Errorf("Register() = %v, want the collector already registered", err)
+		}
+	}
+
+	problems, err := testutil.GatherAndLint(Registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Errorf("metric %s: %s", problem.Metric, problem.Text)
+	}
+}
+
+func TestArmanCollector(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, arman := range []*myv1beta1.Arman{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "b"}},
+	} {
+		if err := indexer.Add(arman); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `
+# HELP arman_controller_armans Number of Armans in the informer cache by namespace.
+# TYPE arman_controller_armans gauge
+arman_controller_armans{namespace="a"} 2
+arman_controller_armans{namespace="b"} 1
+`
+	if err := testutil.CollectAndCompare(NewArmanCollector(mylisters.NewArmanLister(indexer)), strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInformerSyncedCollector(t *testing.T) {
+	collector := NewInformerSyncedCollector(map[string]cache.InformerSynced{
+		"deployments": func() bool { return true },
+		"armans":      func() bool { return false },
+	})
+
+	want := `
+# HELP arman_controller_informer_synced Whether the informer cache has synced (1) or not (0).
+# TYPE arman_controller_informer_synced gauge
+arman_controller_informer_synced{informer="armans"} 0
+arman_controller_informer_synced{informer="deployments"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
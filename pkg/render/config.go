@@ -0,0 +1,157 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// ConfigReferences are the names of the ConfigMaps and Secrets in the
+// namespace of an Arman its pods read, in order.
+type ConfigReferences struct {
+	ConfigMaps []string
+	Secrets    []string
+}
+
+// References returns the ConfigMaps and Secrets the pods of arman read
+// through the environment of their containers, config mounts and volumes.
+func References(arman *myv1beta1.Arman) ConfigReferences {
+	configMaps, secrets := sets.New[string](), sets.New[string]()
+	addEnv := func(env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+		for _, ev := range env {
+			if ev.ValueFrom == nil {
+				continue
+			}
+			if ref := ev.ValueFrom.ConfigMapKeyRef; ref != nil {
+				configMaps.Insert(ref.Name)
+			}
+			if ref := ev.ValueFrom.SecretKeyRef; ref != nil {
+				secrets.Insert(ref.Name)
+			}
+		}
+		for _, ev := range envFrom {
+			if ref := ev.ConfigMapRef; ref != nil {
+				configMaps.Insert(ref.Name)
+			}
+			if ref := ev.SecretRef; ref != nil {
+				secrets.Insert(ref.Name)
+			}
+		}
+	}
+	addEnv(arman.Spec.Workload.Env, arman.Spec.Workload.EnvFrom)
+	for _, container := range arman.Spec.Workload.Sidecars {
+		addEnv(container.Env, container.EnvFrom)
+	}
+	for _, container := range arman.Spec.Workload.InitContainers {
+		addEnv(container.Env, container.EnvFrom)
+	}
+
+	for _, volume := range arman.Spec.Workload.Volumes {
+		if source := volume.ConfigMap; source != nil {
+			configMaps.Insert(source.Name)
+		}
+		if source := volume.Secret; source != nil {
+			secrets.Insert(source.SecretName)
+		}
+		if source := volume.Projected; source != nil {
+			for _, projection := range source.Sources {
+				if projection.ConfigMap != nil {
+					configMaps.Insert(projection.ConfigMap.Name)
+				}
+				if projection.Secret != nil {
+					secrets.Insert(projection.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, mount := range arman.Spec.Workload.ConfigMounts {
+		if mount.ConfigMap != nil {
+			configMaps.Insert(mount.ConfigMap.Name)
+		}
+		if mount.Secret != nil {
+			secrets.Insert(mount.Secret.SecretName)
+		}
+	}
+	return ConfigReferences{ConfigMaps: sets.List(configMaps), Secrets: sets.List(secrets)}
+}
+
+// Empty reports whether no ConfigMap or Secret is referenced.
+func (r ConfigReferences) Empty() bool {
+	return len(r.ConfigMaps) == 0 && len(r.Secrets) == 0
+}
+
+// configContent is what the config hash is computed over for a ConfigMap
+// or Secret.
+type configContent struct {
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Missing    bool              `json:"missing,omitempty"`
+	Data       map[string]string `json:"data,omitempty"`
+	BinaryData map[string][]byte `json:"binaryData,omitempty"`
+}
+
+// ConfigHash returns a hash of the data of the referenced ConfigMaps and
+// Secrets, keyed by name. A nil entry stands for one that does not exist, so
+// that its creation changes the hash too.
+func ConfigHash(configMaps map[string]*corev1.ConfigMap, secrets map[string]*corev1.Secret) string {
+	var contents []configContent
+	for _, name := range sortedKeys(configMaps) {
+		content := configContent{Kind: "ConfigMap", Name: name, Missing: configMaps[name] == nil}
+		if cm := configMaps[name]; cm != nil {
+			content.Data, content.BinaryData = cm.Data, cm.BinaryData
+		}
+		contents = append(contents, content)
+	}
+	for _, name := range sortedKeys(secrets) {
+		content := configContent{Kind: "Secret", Name: name, Missing: secrets[name] == nil}
+		if secret := secrets[name]; secret != nil {
+			content.BinaryData = secret.Data
+		}
+		contents = append(contents, content)
+	}
+
+	// Maps are encoded with sorted keys, so the encoding is stable.
+	data, err := json.Marshal(contents)
+	if err != nil {
+		panic(fmt.Sprintf("encoding config contents: %v", err))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// WithConfigHash annotates the pod template of deployment with hash, so
+// that the pods are rolled when it changes.
+func WithConfigHash(deployment *appsv1ac.DeploymentApplyConfiguration, hash string) *appsv1ac.DeploymentApplyConfiguration {
+	deployment.Spec.Template.WithAnnotations(map[string]string{myv1beta1.ConfigHashAnnotation: hash})
+	return deployment
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyConfiguration fills out, an apply configuration, with the fields of
+// in, the API type it mirrors. Both have the same JSON encoding.
+func applyConfiguration[T any](in interface{}, out *T) *T {
+	data, err := json.Marshal(in)
+	if err == nil {
+		err = json.Unmarshal(data, out)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("converting %T to %T: %v", in, out, err))
+	}
+	return out
+}
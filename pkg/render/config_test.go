@@ -0,0 +1,64 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReferences(t *testing.T) {
+	arman := readArman(t, "testdata/config.arman.yaml")
+
+	want := ConfigReferences{
+		ConfigMaps: []string{"queue-settings"},
+		Secrets:    []string{"queue-db", "queue-tls"},
+	}
+	if diff := cmp.Diff(want, References(arman)); diff != "" {
+		t.Errorf("References() differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	configMap := func(resourceVersion string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", ResourceVersion: resourceVersion}, Data: data}
+	}
+	secret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "settings"}, Data: data}
+	}
+	base := ConfigHash(map[string]*corev1.ConfigMap{"settings": configMap("1", map[string]string{"a": "1"})}, nil)
+
+	tests := []struct {
+		name       string
+		configMaps map[string]*corev1.ConfigMap
+		secrets    map[string]*corev1.Secret
+		wantSame   bool
+	}{
+		{
+			name:       "only metadata changed",
+			configMaps: map[string]*corev1.ConfigMap{"settings": configMap("2", map[string]string{"a": "1"})},
+			wantSame:   true,
+		},
+		{
+			name:       "data changed",
+			configMaps: map[string]*corev1.ConfigMap{"settings": configMap("2", map[string]string{"a": "2"})},
+		},
+		{
+			name:       "configmap missing",
+			configMaps: map[string]*corev1.ConfigMap{"settings": nil},
+		},
+		{
+			name:    "secret of the same name and data",
+			secrets: map[string]*corev1.Secret{"settings": secret(map[string][]byte{"a": []byte("1")})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ConfigHash(tt.configMaps, tt.secrets)
+			if (got == base) != tt.wantSame {
+				t.Errorf("ConfigHash() = %s, base %s, want same %v", got, base, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// WorkloadDefaults are the container settings of a cluster, used for the
+// Armans that leave them unset. They are read from the file given to the
+// controller with --workload-defaults.
+type WorkloadDefaults struct {
+	// Resources are the default requests and limits, by resource.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// LivenessProbe is the default liveness probe.
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// ReadinessProbe is the default readiness probe.
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+	// StartupProbe is the default startup probe.
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+	// Lifecycle are the default lifecycle hooks.
+	Lifecycle *corev1.Lifecycle `json:"lifecycle,omitempty"`
+}
+
+// Apply returns arman with the container settings it leaves unset taken
+// from d, which may be nil. arman itself is not modified.
+//
+// Resources are defaulted one by one, the way a LimitRange does: a request
+// is left unset if the Arman limits the resource, so that the API server
+// takes the limit for it, and a limit is left unset if it is below the
+// request.
+func (d *WorkloadDefaults) Apply(arman *myv1beta1.Arman) *myv1beta1.Arman {
+	if d == nil {
+		return arman
+	}
+	arman = arman.DeepCopy()
+	workload := &arman.Spec.Workload
+
+	resources := &workload.Resources
+	for name, quantity := range d.Resources.Requests {
+		if _, ok := resources.Requests[name]; ok {
+			continue
+		}
+		if _, ok := resources.Limits[name]; ok {
+			continue
+		}
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		resources.Requests[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range d.Resources.Limits {
+		if _, ok := resources.Limits[name]; ok {
+			continue
+		}
+		if request, ok := resources.Requests[name]; ok && quantity.Cmp(request) < 0 {
+			continue
+		}
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[name] = quantity.DeepCopy()
+	}
+
+	if workload.LivenessProbe == nil && d.LivenessProbe != nil {
+		workload.LivenessProbe = d.LivenessProbe.DeepCopy()
+	}
+	if workload.ReadinessProbe == nil && d.ReadinessProbe != nil {
+		workload.ReadinessProbe = d.ReadinessProbe.DeepCopy()
+	}
+	if workload.StartupProbe == nil && d.StartupProbe != nil {
+		workload.StartupProbe = d.StartupProbe.DeepCopy()
+	}
+	if workload.Lifecycle == nil && d.Lifecycle != nil {
+		workload.Lifecycle = d.Lifecycle.DeepCopy()
+	}
+	return arman
+}
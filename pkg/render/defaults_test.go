@@ -0,0 +1,105 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+func TestWorkloadDefaultsApply(t *testing.T) {
+	defaults := &WorkloadDefaults{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+		},
+		ReadinessProbe: &corev1.Probe{PeriodSeconds: 5},
+		Lifecycle:      &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"sleep", "5"}}}},
+	}
+
+	tests := []struct {
+		name     string
+		workload myv1beta1.WorkloadSpec
+		want     myv1beta1.WorkloadSpec
+	}{
+		{
+			name: "everything unset",
+			want: myv1beta1.WorkloadSpec{
+				Resources:      defaults.Resources,
+				ReadinessProbe: defaults.ReadinessProbe,
+				Lifecycle:      defaults.Lifecycle,
+			},
+		},
+		{
+			name: "set fields are kept",
+			workload: myv1beta1.WorkloadSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+				ReadinessProbe: &corev1.Probe{PeriodSeconds: 30},
+			},
+			want: myv1beta1.WorkloadSpec{
+				Resources: corev1.ResourceRequirements{
+					// The default cpu limit is below the request.
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+				ReadinessProbe: &corev1.Probe{PeriodSeconds: 30},
+				Lifecycle:      defaults.Lifecycle,
+			},
+		},
+		{
+			name: "limit without request",
+			workload: myv1beta1.WorkloadSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+				},
+			},
+			want: myv1beta1.WorkloadSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+				},
+				ReadinessProbe: defaults.ReadinessProbe,
+				Lifecycle:      defaults.Lifecycle,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arman := &myv1beta1.Arman{Spec: myv1beta1.ArmanSpec{Workload: tt.workload}}
+			before := arman.DeepCopy()
+
+			got := defaults.Apply(arman)
+
+			if diff := cmp.Diff(tt.want, got.Spec.Workload); diff != "" {
+				t.Errorf("Apply() differs (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(before, arman); diff != "" {
+				t.Errorf("Apply() modified its argument (-before +after):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("nil defaults", func(t *testing.T) {
+		arman := &myv1beta1.Arman{}
+		if got := (*WorkloadDefaults)(nil).Apply(arman); got != arman {
+			t.Errorf("Apply() = %v, want the Arman unchanged", got)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// Objects returns every child object of arman, in the order the controller
+// applies them: the ConfigMap and Secret holding its files, if any, before
+// the Deployment mounting them, then the Service.
+func Objects(arman *myv1beta1.Arman) []interface{} {
+	var objs []interface{}
+	if configMap := ConfigFilesConfigMap(arman); configMap != nil {
+		objs = append(objs, configMap)
+	}
+	if secret := SecretFilesSecret(arman); secret != nil {
+		objs = append(objs, secret)
+	}
+	return append(objs, Deployment(arman), Service(arman))
+}
+
+// filesHashLength is the number of hex digits of the hash of the files the
+// names of the ConfigMap and Secret holding them end in.
+const filesHashLength = 10
+
+// ConfigFilesName returns the name of the ConfigMap holding the ConfigFiles
+// of arman, or an empty string if it has none. The name changes with the
+// files, so that every version gets a ConfigMap of its own.
+func ConfigFilesName(arman *myv1beta1.Arman) string {
+	return filesName(arman, "config", arman.Spec.ConfigFiles)
+}
+
+// SecretFilesName returns the name of the Secret holding the SecretFiles of
+// arman, or an empty string if it has none.
+func SecretFilesName(arman *myv1beta1.Arman) string {
+	return filesName(arman, "secret", arman.Spec.SecretFiles)
+}
+
+func filesName(arman *myv1beta1.Arman, infix string, files map[string]string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	// Maps are encoded with sorted keys, so the encoding is stable.
+	data, err := json.Marshal(files)
+	if err != nil {
+		panic(fmt.Sprintf("encoding files: %v", err))
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%s-%s-%s", arman.Spec.Workload.Name, infix, hex.EncodeToString(sum[:])[:filesHashLength])
+}
+
+// filesAnnotations returns the annotations of the ConfigMap and Secret
+// holding the files of arman. Only their data is immutable, so the
+// annotations are updated every time they are applied.
+func filesAnnotations(arman *myv1beta1.Arman) map[string]string {
+	return map[string]string{myv1beta1.LastUsedGenerationAnnotation: strconv.FormatInt(arman.Generation, 10)}
+}
+
+// ConfigFilesConfigMap returns the ConfigMap holding the ConfigFiles of
+// arman, or nil if it has none. It is immutable: other files go into
+// another ConfigMap.
+func ConfigFilesConfigMap(arman *myv1beta1.Arman) *corev1ac.ConfigMapApplyConfiguration {
+	name := ConfigFilesName(arman)
+	if name == "" {
+		return nil
+	}
+	return corev1ac.ConfigMap(name, arman.Namespace).
+		WithLabels(Labels(arman)).
+		WithAnnotations(filesAnnotations(arman)).
+		WithOwnerReferences(OwnerReference(arman)).
+		WithImmutable(true).
+		WithData(arman.Spec.ConfigFiles)
+}
+
+// SecretFilesSecret returns the Secret holding the SecretFiles of arman, or
+// nil if it has none. It is immutable like the ConfigMap returned by
+// ConfigFilesConfigMap.
+func SecretFilesSecret(arman *myv1beta1.Arman) *corev1ac.SecretApplyConfiguration {
+	name := SecretFilesName(arman)
+	if name == "" {
+		return nil
+	}
+	data := make(map[string][]byte, len(arman.Spec.SecretFiles))
+	for key, value := range arman.Spec.SecretFiles {
+		data[key] = []byte(value)
+	}
+	return corev1ac.Secret(name, arman.Namespace).
+		WithLabels(Labels(arman)).
+		WithAnnotations(filesAnnotations(arman)).
+		WithOwnerReferences(OwnerReference(arman)).
+		WithImmutable(true).
+		WithData(data)
+}
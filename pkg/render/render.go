@@ -0,0 +1,223 @@
+// Package render turns an Arman into the Kubernetes objects the controller
+// owns for it. Every field of the Arman spec that ends up in a child object
+// is mapped here, so the functions are kept free of any client or cache
+// access and can be used both by the controller and by tests.
+//
+// The objects are returned as apply configurations: they hold exactly the
+// fields the controller owns and are sent with server-side apply, so fields
+// set by other actors are left alone.
+package render
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// ContainerName is the name of the container running Workload.Image.
+const ContainerName = myv1beta1.ContainerName
+
+// Labels returns the labels put on the pods of an Arman and used as the
+// Deployment and Service selector. They only depend on the Arman's name so
+// the selector, which is immutable on a Deployment, never changes.
+func Labels(arman *myv1beta1.Arman) map[string]string {
+	return map[string]string{
+		"app":        "arman",
+		"controller": arman.Name,
+	}
+}
+
+// OwnerReference returns the controller reference every child object of an
+// Arman carries.
+func OwnerReference(arman *myv1beta1.Arman) *metav1ac.OwnerReferenceApplyConfiguration {
+	return metav1ac.OwnerReference().
+		WithAPIVersion(myv1beta1.SchemeGroupVersion.String()).
+		WithKind("Arman").
+		WithName(arman.Name).
+		WithUID(arman.UID).
+		WithController(true).
+		WithBlockOwnerDeletion(true)
+}
+
+// Deployment returns the Deployment described by the Arman spec.
+func Deployment(arman *myv1beta1.Arman) *appsv1ac.DeploymentApplyConfiguration {
+	labels := Labels(arman)
+	container := corev1ac.Container().
+		WithName(ContainerName).
+		WithImage(arman.Spec.Workload.Image)
+	// Ports targeting the same container port share it; ports targeting
+	// another port by name have none of their own.
+	type containerPort struct {
+		port     int32
+		protocol corev1.Protocol
+	}
+	containerPorts := map[containerPort]bool{}
+	for _, port := range servicePorts(arman) {
+		key := containerPort{port: port.TargetPort.IntVal, protocol: port.Protocol}
+		if port.TargetPort.Type != intstr.Int || containerPorts[key] {
+			continue
+		}
+		containerPorts[key] = true
+		cp := corev1ac.ContainerPort().
+			WithContainerPort(key.port).
+			WithProtocol(key.protocol)
+		if port.Name != "" {
+			cp.WithName(port.Name)
+		}
+		container.WithPorts(cp)
+	}
+	if resources := arman.Spec.Workload.Resources; len(resources.Limits) > 0 || len(resources.Requests) > 0 || len(resources.Claims) > 0 {
+		container.WithResources(applyConfiguration(&resources, &corev1ac.ResourceRequirementsApplyConfiguration{}))
+	}
+	if probe := arman.Spec.Workload.LivenessProbe; probe != nil {
+		container.WithLivenessProbe(applyConfiguration(probe, &corev1ac.ProbeApplyConfiguration{}))
+	}
+	if probe := arman.Spec.Workload.ReadinessProbe; probe != nil {
+		container.WithReadinessProbe(applyConfiguration(probe, &corev1ac.ProbeApplyConfiguration{}))
+	}
+	if probe := arman.Spec.Workload.StartupProbe; probe != nil {
+		container.WithStartupProbe(applyConfiguration(probe, &corev1ac.ProbeApplyConfiguration{}))
+	}
+	if lifecycle := arman.Spec.Workload.Lifecycle; lifecycle != nil {
+		container.WithLifecycle(applyConfiguration(lifecycle, &corev1ac.LifecycleApplyConfiguration{}))
+	}
+	podSpec := corev1ac.PodSpec()
+	for i := range arman.Spec.Workload.Env {
+		container.WithEnv(applyConfiguration(&arman.Spec.Workload.Env[i], &corev1ac.EnvVarApplyConfiguration{}))
+	}
+	for i := range arman.Spec.Workload.EnvFrom {
+		container.WithEnvFrom(applyConfiguration(&arman.Spec.Workload.EnvFrom[i], &corev1ac.EnvFromSourceApplyConfiguration{}))
+	}
+	for _, mount := range arman.Spec.Workload.ConfigMounts {
+		container.WithVolumeMounts(corev1ac.VolumeMount().
+			WithName(mount.Name).
+			WithMountPath(mount.MountPath).
+			WithReadOnly(true))
+		volume := corev1ac.Volume().WithName(mount.Name)
+		if mount.ConfigMap != nil {
+			volume.WithConfigMap(applyConfiguration(mount.ConfigMap, &corev1ac.ConfigMapVolumeSourceApplyConfiguration{}))
+		}
+		if mount.Secret != nil {
+			volume.WithSecret(applyConfiguration(mount.Secret, &corev1ac.SecretVolumeSourceApplyConfiguration{}))
+		}
+		podSpec.WithVolumes(volume)
+	}
+	if name := ConfigFilesName(arman); name != "" {
+		container.WithVolumeMounts(corev1ac.VolumeMount().
+			WithName(myv1beta1.ConfigFilesVolumeName).
+			WithMountPath(myv1beta1.ConfigFilesMountPath).
+			WithReadOnly(true))
+		podSpec.WithVolumes(corev1ac.Volume().
+			WithName(myv1beta1.ConfigFilesVolumeName).
+			WithConfigMap(corev1ac.ConfigMapVolumeSource().WithName(name)))
+	}
+	if name := SecretFilesName(arman); name != "" {
+		container.WithVolumeMounts(corev1ac.VolumeMount().
+			WithName(myv1beta1.SecretFilesVolumeName).
+			WithMountPath(myv1beta1.SecretFilesMountPath).
+			WithReadOnly(true))
+		podSpec.WithVolumes(corev1ac.Volume().
+			WithName(myv1beta1.SecretFilesVolumeName).
+			WithSecret(corev1ac.SecretVolumeSource().WithSecretName(name)))
+	}
+	for i := range arman.Spec.Workload.VolumeMounts {
+		container.WithVolumeMounts(applyConfiguration(&arman.Spec.Workload.VolumeMounts[i], &corev1ac.VolumeMountApplyConfiguration{}))
+	}
+	for i := range arman.Spec.Workload.Volumes {
+		podSpec.WithVolumes(applyConfiguration(&arman.Spec.Workload.Volumes[i], &corev1ac.VolumeApplyConfiguration{}))
+	}
+
+	// The sidecars and init containers are taken as they are. Every
+	// container is owned by the controller, so changes made to any of them
+	// by others are reverted like those to the container.
+	podSpec.WithContainers(container)
+	for i := range arman.Spec.Workload.Sidecars {
+		podSpec.WithContainers(containerApplyConfiguration(&arman.Spec.Workload.Sidecars[i]))
+	}
+	for i := range arman.Spec.Workload.InitContainers {
+		podSpec.WithInitContainers(containerApplyConfiguration(&arman.Spec.Workload.InitContainers[i]))
+	}
+
+	spec := appsv1ac.DeploymentSpec().
+		WithSelector(metav1ac.LabelSelector().
+			WithMatchLabels(labels)).
+		WithTemplate(corev1ac.PodTemplateSpec().
+			WithLabels(labels).
+			WithSpec(podSpec))
+	// Replicas are only owned while the Arman sets them, so that an HPA can
+	// manage them otherwise. Leaving them out of the apply releases them.
+	if arman.Spec.Workload.Replicas != nil {
+		spec.WithReplicas(*arman.Spec.Workload.Replicas)
+	}
+
+	return appsv1ac.Deployment(arman.Spec.Workload.Name, arman.Namespace).
+		WithLabels(labels).
+		WithOwnerReferences(OwnerReference(arman)).
+		WithSpec(spec)
+}
+
+// Service returns the Service described by the Arman spec. It selects the
+// pods of the Deployment returned by Deployment. The ClusterIP, and the
+// NodePort unless the spec sets one, are left for the API server to
+// allocate.
+func Service(arman *myv1beta1.Arman) *corev1ac.ServiceApplyConfiguration {
+	labels := Labels(arman)
+	spec := corev1ac.ServiceSpec().
+		WithType(corev1.ServiceType(arman.Spec.Service.Type)).
+		WithSelector(labels)
+	for _, p := range servicePorts(arman) {
+		port := corev1ac.ServicePort().
+			WithPort(p.Port).
+			WithTargetPort(p.TargetPort).
+			WithProtocol(p.Protocol)
+		if p.Name != "" {
+			port.WithName(p.Name)
+		}
+		if p.AppProtocol != nil {
+			port.WithAppProtocol(*p.AppProtocol)
+		}
+		// An allocated NodePort is only owned once the Arman pins it.
+		if p.NodePort != 0 {
+			port.WithNodePort(p.NodePort)
+		}
+		spec.WithPorts(port)
+	}
+
+	return corev1ac.Service(arman.Spec.Service.Name, arman.Namespace).
+		WithLabels(labels).
+		WithOwnerReferences(OwnerReference(arman)).
+		WithSpec(spec)
+}
+
+// containerApplyConfiguration returns the apply configuration of a sidecar
+// or init container. The protocol of its ports, which is part of their key,
+// defaults to TCP the way the API server defaults it, and resources it does
+// not have are left out rather than owned as empty.
+func containerApplyConfiguration(container *corev1.Container) *corev1ac.ContainerApplyConfiguration {
+	ac := applyConfiguration(container, &corev1ac.ContainerApplyConfiguration{})
+	for i := range ac.Ports {
+		if ac.Ports[i].Protocol == nil {
+			ac.Ports[i].WithProtocol(corev1.ProtocolTCP)
+		}
+	}
+	if resources := container.Resources; len(resources.Limits) == 0 && len(resources.Requests) == 0 && len(resources.Claims) == 0 {
+		ac.Resources = nil
+	}
+	return ac
+}
+
+// servicePorts returns the ports of the Service of arman, defaulted. An
+// Arman that was not defaulted may still describe its only port with the
+// single port fields of its ServiceSpec.
+func servicePorts(arman *myv1beta1.Arman) []myv1beta1.ServicePort {
+	service := arman.Spec.Service.DeepCopy()
+	myv1beta1.SetDefaults_ServiceSpec(service)
+	for i := range service.Ports {
+		myv1beta1.SetDefaults_ServicePort(&service.Ports[i])
+	}
+	return service.Ports
+}
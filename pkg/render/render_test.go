@@ -0,0 +1,78 @@
+package render
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata with the current output")
+
+// TestRender renders every testdata/<name>.arman.yaml and compares the
+// resulting objects with testdata/<name>.golden.yaml. Run
+// `go test ./pkg/render -update` to accept an intended change.
+func TestRender(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.arman.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test inputs found in testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".arman.yaml")
+		t.Run(name, func(t *testing.T) {
+			arman := readArman(t, input)
+
+			got := marshal(t, Objects(arman)...)
+
+			golden := filepath.Join("testdata", name+".golden.yaml")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Errorf("rendered objects differ from %s (-want +got):\n%s", golden, diff)
+			}
+		})
+	}
+}
+
+func readArman(t *testing.T, path string) *myv1beta1.Arman {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arman := &myv1beta1.Arman{}
+	if err := yaml.UnmarshalStrict(data, arman); err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	return arman
+}
+
+func marshal(t *testing.T, objs ...interface{}) []byte {
+	t.Helper()
+	var docs []string
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, string(data))
+	}
+	return []byte(strings.Join(docs, "---\n"))
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// maxRequestBytes bounds the size of an AdmissionReview, well above the
+// largest object the API server stores.
+const maxRequestBytes = 7 * 1024 * 1024
+
+// admitFunc reviews an admission request.
+type admitFunc func(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+// admissionHandler decodes the admission.k8s.io/v1 AdmissionReview sent by
+// the API server, passes its request to admit and sends the response back.
+func admissionHandler(admit admitFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		review := &admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, review); err != nil {
+			http.Error(w, fmt.Sprintf("error decoding AdmissionReview: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		if review.Request == nil {
+			http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+			return
+		}
+
+		response := admit(review.Request)
+		response.UID = review.Request.UID
+		review = &admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: admissionv1.SchemeGroupVersion.String(),
+				Kind:       "AdmissionReview",
+			},
+			Response: response,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			klog.Errorf("Error writing AdmissionReview response: %s", err.Error())
+		}
+	})
+}
+
+// readBody reads the JSON body of a review sent by the API server. If the
+// request is not one, the error is sent back and ok is false.
+func readBody(w http.ResponseWriter, r *http.Request) (body []byte, ok bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		http.Error(w, fmt.Sprintf("unsupported content type %q, only application/json is supported", contentType), http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request: %s", err.Error()), http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// allowed returns a response admitting the request.
+func allowed() *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+// denied returns a response rejecting the request with status.
+func denied(status metav1.Status) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: false, Result: &status}
+}
+
+// errored returns a response rejecting a request that could not be
+// reviewed.
+func errored(code int32, err error) *admissionv1.AdmissionResponse {
+	return denied(metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    code,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	myclientscheme "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/scheme"
+)
+
+// ConvertPath is the path the conversion webhook is served at.
+const ConvertPath = "/convert"
+
+// ConversionHandler returns the handler of the conversion webhook of the
+// Arman CRD. Armans are converted between the versions registered with the
+// clientset scheme by the conversion functions of the API packages, without
+// defaulting.
+func ConversionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		review := &apiextensionsv1.ConversionReview{}
+		if err := json.Unmarshal(body, review); err != nil {
+			http.Error(w, fmt.Sprintf("error decoding ConversionReview: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		if review.Request == nil {
+			http.Error(w, "ConversionReview has no request", http.StatusBadRequest)
+			return
+		}
+
+		response := convert(review.Request)
+		response.UID = review.Request.UID
+		review = &apiextensionsv1.ConversionReview{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+				Kind:       "ConversionReview",
+			},
+			Response: response,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			klog.Errorf("Error writing ConversionReview response: %s", err.Error())
+		}
+	})
+}
+
+func convert(req *apiextensionsv1.ConversionRequest) *apiextensionsv1.ConversionResponse {
+	desired, err := schema.ParseGroupVersion(req.DesiredAPIVersion)
+	if err != nil {
+		return conversionFailed(err)
+	}
+
+	converted := make([]runtime.RawExtension, 0, len(req.Objects))
+	for i := range req.Objects {
+		raw, err := convertObject(req.Objects[i].Raw, desired)
+		if err != nil {
+			return conversionFailed(fmt.Errorf("converting object %d: %w", i, err))
+		}
+		converted = append(converted, runtime.RawExtension{Raw: raw})
+	}
+	return &apiextensionsv1.ConversionResponse{
+		ConvertedObjects: converted,
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+}
+
+// convertObject converts the JSON object raw to the version desired.
+func convertObject(raw []byte, desired schema.GroupVersion) ([]byte, error) {
+	typeMeta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, typeMeta); err != nil {
+		return nil, err
+	}
+	gvk := typeMeta.GroupVersionKind()
+	if gvk.GroupVersion() == desired {
+		return raw, nil
+	}
+
+	in, err := myclientscheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, in); err != nil {
+		return nil, err
+	}
+	out, err := myclientscheme.Scheme.New(desired.WithKind(gvk.Kind))
+	if err != nil {
+		return nil, err
+	}
+	if err := myclientscheme.Scheme.Convert(in, out, nil); err != nil {
+		return nil, err
+	}
+	out.GetObjectKind().SetGroupVersionKind(desired.WithKind(gvk.Kind))
+	return json.Marshal(out)
+}
+
+// conversionFailed returns a response failing a conversion request.
+func conversionFailed(err error) *apiextensionsv1.ConversionResponse {
+	return &apiextensionsv1.ConversionResponse{
+		Result: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// DefaultPath is the path the defaulting webhook for Armans is served on.
+const DefaultPath = "/mutate-arman-com-v1beta1-arman"
+
+// jsonPatchOperation is an operation of an RFC 6902 JSON patch.
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// DefaultingHandler returns the handler of the mutating webhook for Armans.
+// It sets the fields the defaulting functions registered with the scheme
+// set, and leaves every other field untouched.
+func DefaultingHandler() http.Handler {
+	return admissionHandler(defaultArman)
+}
+
+func defaultArman(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Group != myv1beta1.SchemeGroupVersion.Group || req.Kind.Kind != "Arman" {
+		return errored(http.StatusBadRequest, fmt.Errorf("unexpected kind %s", req.Kind))
+	}
+	if req.Kind.Version != myv1beta1.SchemeGroupVersion.Version {
+		return errored(http.StatusBadRequest, fmt.Errorf("unsupported version %s, only %s is supported", req.Kind.Version, myv1beta1.SchemeGroupVersion.Version))
+	}
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allowed()
+	}
+
+	arman := &myv1beta1.Arman{}
+	if err := json.Unmarshal(req.Object.Raw, arman); err != nil {
+		return errored(http.StatusBadRequest, err)
+	}
+	// The raw object tells which fields were sent at all.
+	var raw map[string]interface{}
+	if err := json.Unmarshal(req.Object.Raw, &raw); err != nil {
+		return errored(http.StatusBadRequest, err)
+	}
+
+	defaulted := arman.DeepCopy()
+	myv1beta1.SetObjectDefaults_Arman(defaulted)
+
+	patch, err := defaultingPatch(raw, arman, defaulted)
+	if err != nil {
+		return errored(http.StatusInternalServerError, err)
+	}
+	if len(patch) == 0 {
+		return allowed()
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return errored(http.StatusInternalServerError, err)
+	}
+	klog.V(4).Infof("Defaulting arman %s/%s: %s", req.Namespace, req.Name, patchBytes)
+
+	patchType := admissionv1.PatchTypeJSONPatch
+	response := allowed()
+	response.Patch = patchBytes
+	response.PatchType = &patchType
+	return response
+}
+
+// defaultingPatch returns the JSON patch adding the spec fields and labels
+// set by defaulting arman into defaulted. Only the fields defaulting changed
+// are added, so that fields this version of the controller does not know
+// about are kept.
+func defaultingPatch(raw map[string]interface{}, arman, defaulted *myv1beta1.Arman) ([]jsonPatchOperation, error) {
+	spec, err := toMap(arman.Spec)
+	if err != nil {
+		return nil, err
+	}
+	defaultedSpec, err := toMap(defaulted.Spec)
+	if err != nil {
+		return nil, err
+	}
+	labels, defaultedLabels := map[string]interface{}{}, map[string]interface{}{}
+	for key, value := range arman.Labels {
+		labels[key] = value
+	}
+	for key, value := range defaulted.Labels {
+		defaultedLabels[key] = value
+	}
+	return addOperations("", raw, map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": changedFields(labels, defaultedLabels)},
+		"spec":     changedFields(spec, defaultedSpec),
+	}), nil
+}
+
+// changedFields returns the fields of defaulted that differ from original,
+// descending into the objects and lists both have.
+func changedFields(original, defaulted map[string]interface{}) map[string]interface{} {
+	changed := map[string]interface{}{}
+	for key, value := range defaulted {
+		if reflect.DeepEqual(original[key], value) {
+			continue
+		}
+		changed[key] = changedValue(original[key], value)
+	}
+	return changed
+}
+
+// changedList holds the changes defaulting made to the elements of a list
+// that kept its length.
+type changedList struct {
+	// elements holds what changed of every element, as returned by
+	// changedValue, and nil for the elements that did not change.
+	elements []interface{}
+	// value is the whole defaulted list.
+	value []interface{}
+}
+
+// MarshalJSON encodes the whole defaulted list, for when it is added as
+// part of an object that was not sent.
+func (l changedList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.value)
+}
+
+// changedValue returns what of defaulted differs from original: the changed
+// fields of an object, the changed elements of a list of the same length, or
+// defaulted itself.
+func changedValue(original, defaulted interface{}) interface{} {
+	switch defaulted := defaulted.(type) {
+	case map[string]interface{}:
+		if originalObject, ok := original.(map[string]interface{}); ok {
+			return changedFields(originalObject, defaulted)
+		}
+	case []interface{}:
+		if originalList, ok := original.([]interface{}); ok && len(originalList) == len(defaulted) {
+			list := changedList{elements: make([]interface{}, len(defaulted)), value: defaulted}
+			for i, element := range defaulted {
+				if !reflect.DeepEqual(originalList[i], element) {
+					list.elements[i] = changedValue(originalList[i], element)
+				}
+			}
+			return list
+		}
+	}
+	return defaulted
+}
+
+// addOperations returns the operations adding changed to the object at path,
+// whose fields as sent are raw. Objects and lists that were sent are patched
+// field by field and element by element, so that the fields in them this
+// version of the controller does not know about are kept.
+func addOperations(path string, raw, changed map[string]interface{}) []jsonPatchOperation {
+	var patch []jsonPatchOperation
+	for _, key := range sortedKeys(changed) {
+		patch = append(patch, valueOperations("add", path+"/"+escapeJSONPointer(key), raw[key], changed[key])...)
+	}
+	return patch
+}
+
+// valueOperations returns the operations setting the value at path, sent as
+// raw, to changed with op. Operations on list elements replace them, as
+// adding would insert them.
+func valueOperations(op, path string, raw, changed interface{}) []jsonPatchOperation {
+	switch changed := changed.(type) {
+	case map[string]interface{}:
+		if len(changed) == 0 {
+			return nil
+		}
+		if rawObject, ok := raw.(map[string]interface{}); ok {
+			return addOperations(path, rawObject, changed)
+		}
+	case changedList:
+		rawList, ok := raw.([]interface{})
+		if !ok || len(rawList) != len(changed.elements) {
+			return []jsonPatchOperation{{Op: op, Path: path, Value: changed.value}}
+		}
+		var patch []jsonPatchOperation
+		for i, element := range changed.elements {
+			if element == nil {
+				continue
+			}
+			patch = append(patch, valueOperations("replace", path+"/"+strconv.Itoa(i), rawList[i], element)...)
+		}
+		return patch
+	}
+	return []jsonPatchOperation{{Op: op, Path: path, Value: changed}}
+}
+
+// toMap returns the JSON object obj is serialized to.
+func toMap(obj interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapeJSONPointer escapes a key for use in a JSON pointer, as the labels
+// keys may contain slashes.
+func escapeJSONPointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/go-cmp/cmp"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// defaultRaw sends raw to the defaulting webhook and returns it with the
+// patch of the response applied.
+func defaultRaw(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	resp := review(t, DefaultingHandler(), &admissionv1.AdmissionRequest{
+		UID:       "request",
+		Kind:      metav1.GroupVersionKind{Group: myv1beta1.SchemeGroupVersion.Group, Version: myv1beta1.SchemeGroupVersion.Version, Kind: "Arman"},
+		Name:      "web",
+		Namespace: metav1.NamespaceDefault,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: []byte(raw)},
+	})
+	if !resp.Allowed {
+		t.Fatalf("request denied: %v", resp.Result)
+	}
+
+	patched := []byte(raw)
+	if len(resp.Patch) > 0 {
+		if resp.PatchType == nil || *resp.PatchType != admissionv1.PatchTypeJSONPatch {
+			t.Fatalf("patch type = %v, want %s", resp.PatchType, admissionv1.PatchTypeJSONPatch)
+		}
+		patch, err := jsonpatch.DecodePatch(resp.Patch)
+		if err != nil {
+			t.Fatalf("decoding patch %s: %v", resp.Patch, err)
+		}
+		if patched, err = patch.Apply(patched); err != nil {
+			t.Fatalf("applying patch %s: %v", resp.Patch, err)
+		}
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(patched, &got); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+// fromJSON returns the JSON object s.
+func fromJSON(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDefaultingHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			// Fields of list items this version does not know about, like
+			// ones added by a newer version of the CRD, are kept.
+			name: "unknown fields in list items",
+			raw: `{
+				"apiVersion": "arman.com/v1beta1", "kind": "Arman",
+				"metadata": {"name": "web", "namespace": "default", "labels": {"team": "a"}},
+				"spec": {
+					"x-future": "kept",
+					"workload": {
+						"image": "registry.example.com/web:v1",
+						"sidecars": [{"name": "proxy", "image": "envoy", "x-future": "kept", "ports": [{"containerPort": 8443, "x-future": "kept"}]}]
+					},
+					"service": {"ports": [
+						{"name": "http", "port": 80, "x-future": "kept"},
+						{"name": "dns", "port": 53, "protocol": "UDP", "targetPort": 5353, "x-future": "kept"}
+					]}
+				}
+			}`,
+			want: `{
+				"apiVersion": "arman.com/v1beta1", "kind": "Arman",
+				"metadata": {"name": "web", "namespace": "default", "labels": {"team": "a", "app.kubernetes.io/name": "arman", "app.kubernetes.io/instance": "web"}},
+				"spec": {
+					"x-future": "kept",
+					"deletionPolicy": "Delete",
+					"renamePolicy": "Forbid",
+					"configRevisionHistoryLimit": 10,
+					"workload": {
+						"name": "web",
+						"image": "registry.example.com/web:v1",
+						"sidecars": [{"name": "proxy", "image": "envoy", "x-future": "kept", "ports": [{"containerPort": 8443, "protocol": "TCP", "x-future": "kept"}]}]
+					},
+					"service": {"name": "web", "type": "ClusterIP", "ports": [
+						{"name": "http", "port": 80, "targetPort": 80, "protocol": "TCP", "x-future": "kept"},
+						{"name": "dns", "port": 53, "protocol": "UDP", "targetPort": 5353, "x-future": "kept"}
+					]}
+				}
+			}`,
+		},
+		{
+			name: "ports defaulted from the single port",
+			raw: `{
+				"metadata": {"name": "web"},
+				"spec": {"workload": {"image": "web"}, "service": {"port": 80, "x-future": "kept"}}
+			}`,
+			want: `{
+				"metadata": {"name": "web", "labels": {"app.kubernetes.io/name": "arman", "app.kubernetes.io/instance": "web"}},
+				"spec": {
+					"deletionPolicy": "Delete",
+					"renamePolicy": "Forbid",
+					"configRevisionHistoryLimit": 10,
+					"workload": {"name": "web", "image": "web"},
+					"service": {"name": "web", "type": "ClusterIP", "port": 80, "targetPort": 80, "x-future": "kept",
+						"ports": [{"port": 80, "targetPort": 80, "protocol": "TCP"}]}
+				}
+			}`,
+		},
+		{
+			name: "no service",
+			raw:  `{"metadata": {"name": "web"}, "spec": {"workload": {"image": "web"}}}`,
+			want: `{
+				"metadata": {"name": "web", "labels": {"app.kubernetes.io/name": "arman", "app.kubernetes.io/instance": "web"}},
+				"spec": {
+					"deletionPolicy": "Delete",
+					"renamePolicy": "Forbid",
+					"configRevisionHistoryLimit": 10,
+					"workload": {"name": "web", "image": "web"},
+					"service": {"name": "web", "type": "ClusterIP"}
+				}
+			}`,
+		},
+		{
+			name: "no spec",
+			raw:  `{"metadata": {"name": "web"}}`,
+			want: `{
+				"metadata": {"name": "web", "labels": {"app.kubernetes.io/name": "arman", "app.kubernetes.io/instance": "web"}},
+				"spec": {
+					"deletionPolicy": "Delete",
+					"renamePolicy": "Forbid",
+					"configRevisionHistoryLimit": 10,
+					"workload": {"name": "web"},
+					"service": {"name": "web", "type": "ClusterIP"}
+				}
+			}`,
+		},
+		{
+			name: "no metadata",
+			raw:  `{"spec": {"workload": {"name": "web", "image": "web", "replicas": 2}, "service": {"name": "web", "type": "NodePort"}}}`,
+			want: `{
+				"metadata": {"labels": {"app.kubernetes.io/name": "arman"}},
+				"spec": {
+					"deletionPolicy": "Delete",
+					"renamePolicy": "Forbid",
+					"configRevisionHistoryLimit": 10,
+					"workload": {"name": "web", "image": "web", "replicas": 2},
+					"service": {"name": "web", "type": "NodePort"}
+				}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaultRaw(t, tt.raw)
+			if diff := cmp.Diff(fromJSON(t, tt.want), got); diff != "" {
+				t.Errorf("defaulted object mismatch (-want +got):\n%s", diff)
+			}
+
+			// Defaulting is idempotent.
+			patched, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again := defaultRaw(t, string(patched)); !cmp.Equal(got, again) {
+				t.Errorf("defaulting the defaulted object changed it:\n%s", cmp.Diff(got, again))
+			}
+		})
+	}
+}
+
+func TestDefaultingHandlerDelete(t *testing.T) {
+	resp := review(t, DefaultingHandler(), &admissionv1.AdmissionRequest{
+		UID:       "request",
+		Kind:      metav1.GroupVersionKind{Group: myv1beta1.SchemeGroupVersion.Group, Version: myv1beta1.SchemeGroupVersion.Version, Kind: "Arman"},
+		Operation: admissionv1.Delete,
+	})
+	if !resp.Allowed || len(resp.Patch) != 0 {
+		t.Errorf("response = %+v, want allowed without a patch", resp)
+	}
+}
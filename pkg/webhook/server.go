@@ -0,0 +1,165 @@
+// Package webhook serves the admission webhooks of the controller over TLS.
+//
+// The API server only talks to webhooks over HTTPS, so the Server needs a
+// serving certificate, as written by cert-manager or a similar tool to a
+// directory mounted from a Secret. The certificate is reloaded when those
+// files change, so it can be rotated without a restart.
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CertFileName and KeyFileName are the names of the serving certificate
+	// and its key in the certificate directory, as found in a
+	// kubernetes.io/tls Secret.
+	CertFileName = "tls.crt"
+	KeyFileName  = "tls.key"
+)
+
+// Server serves admission webhooks over TLS.
+type Server struct {
+	addr string
+	mux  *http.ServeMux
+	cert *certificate
+
+	// serving is set once the Server listens on addr.
+	serving atomic.Bool
+}
+
+// NewServer returns a Server listening on addr with the serving certificate
+// found in certDir.
+func NewServer(addr, certDir string) *Server {
+	return &Server{
+		addr: addr,
+		mux:  http.NewServeMux(),
+		cert: &certificate{
+			certFile: filepath.Join(certDir, CertFileName),
+			keyFile:  filepath.Join(certDir, KeyFileName),
+		},
+	}
+}
+
+// Handle registers the webhook handler for path.
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+}
+
+// Run serves the webhooks until ctx is done.
+func (s *Server) Run(ctx context.Context) error {
+	// Fail early on a missing or broken certificate rather than on the
+	// first request.
+	if _, err := s.cert.get(); err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:    s.addr,
+		Handler: s.mux,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return s.cert.get()
+			},
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("Error shutting down webhook server: %s", err.Error())
+		}
+	}()
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.serving.Store(true)
+	defer s.serving.Store(false)
+
+	klog.Infof("Serving webhooks on %s", s.addr)
+	// The certificate comes from TLSConfig.GetCertificate.
+	if err := server.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// CheckServing fails unless the Server is listening for webhook requests.
+func (s *Server) CheckServing() error {
+	if !s.serving.Load() {
+		return fmt.Errorf("webhook server is not serving")
+	}
+	return nil
+}
+
+// certificate is a key pair loaded from files, reloaded when they are
+// modified.
+type certificate struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	modTime time.Time
+	cert    *tls.Certificate
+}
+
+func (c *certificate) get() (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			// Both files are replaced on rotation, so one may briefly be
+			// missing; keep serving the current certificate.
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	if c.cert != nil && modTime.Equal(c.modTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			klog.Errorf("Error reloading webhook certificate, keeping the current one: %s", err.Error())
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("error loading webhook certificate: %w", err)
+	}
+	klog.Infof("Loaded webhook certificate %s", c.certFile)
+	c.cert, c.modTime = &cert, modTime
+	return c.cert, nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certutil "k8s.io/client-go/util/cert"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// startServer runs a Server with the serving certificate of localhost on a
+// free port until the test ends, and returns its address and a client
+// trusting the certificate.
+func startServer(t *testing.T, handlers map[string]http.Handler) (string, *http.Client) {
+	t.Helper()
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey("localhost", []net.IP{net.ParseIP("127.0.0.1")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(certDir, CertFileName), certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(certDir, KeyFileName), keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	server := NewServer(addr, certDir)
+	for path, handler := range handlers {
+		server.Handle(path, handler)
+	}
+	if err := server.CheckServing(); err == nil {
+		t.Error("CheckServing() = nil before Run, want an error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() = %v, want nil", err)
+		}
+	})
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return server.CheckServing() == nil, nil
+	}); err != nil {
+		t.Fatalf("server not serving: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	t.Cleanup(client.CloseIdleConnections)
+	return addr, client
+}
+
+// TestServerConvertsStoredV1alpha1 starts the conversion webhook the way
+// every replica does, without a controller, leadership or synced caches,
+// and lists Armans stored as v1alpha1 through it as v1beta1, which is what
+// the Arman informer of the leader needs to sync.
+func TestServerConvertsStoredV1alpha1(t *testing.T) {
+	addr, client := startServer(t, map[string]http.Handler{ConvertPath: ConversionHandler()})
+
+	stored := `{
+		"apiVersion": "arman.com/v1alpha1", "kind": "Arman",
+		"metadata": {"name": "web", "namespace": "default"},
+		"spec": {"deploymentName": "web", "deploymentImage": "registry.example.com/web:v1", "replicas": 2, "serviceName": "web", "servicePort": 80}
+	}`
+	body, err := json.Marshal(&apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               "list",
+			DesiredAPIVersion: myv1beta1.SchemeGroupVersion.String(),
+			Objects:           []runtime.RawExtension{{Raw: []byte(stored)}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Post("https://"+addr+ConvertPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	review := &apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(resp.Body).Decode(review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Response == nil || review.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("response = %+v, want a successful conversion", review.Response)
+	}
+	if len(review.Response.ConvertedObjects) != 1 {
+		t.Fatalf("converted %d objects, want 1", len(review.Response.ConvertedObjects))
+	}
+	arman := &myv1beta1.Arman{}
+	if err := json.Unmarshal(review.Response.ConvertedObjects[0].Raw, arman); err != nil {
+		t.Fatal(err)
+	}
+	if arman.APIVersion != myv1beta1.SchemeGroupVersion.String() {
+		t.Errorf("apiVersion = %q, want %q", arman.APIVersion, myv1beta1.SchemeGroupVersion)
+	}
+	if arman.Spec.Workload.Image != "registry.example.com/web:v1" || arman.Spec.Workload.Replicas == nil || *arman.Spec.Workload.Replicas != 2 || arman.Spec.Service.Port != 80 {
+		t.Errorf("converted spec = %+v, want the image, replicas and port of the stored Arman", arman.Spec)
+	}
+}
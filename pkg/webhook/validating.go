@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	"github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/validation"
+)
+
+// ValidatePath is the path the validating webhook for Armans is served on.
+const ValidatePath = "/validate-arman-com-v1beta1-arman"
+
+// ValidatingHandler returns the handler of the validating webhook for
+// Armans. Creates and updates are checked by the validation package; every
+// other operation is allowed.
+func ValidatingHandler() http.Handler {
+	return admissionHandler(validateArman)
+}
+
+func validateArman(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Group != myv1beta1.SchemeGroupVersion.Group || req.Kind.Kind != "Arman" {
+		return errored(http.StatusBadRequest, fmt.Errorf("unexpected kind %s", req.Kind))
+	}
+	if req.Kind.Version != myv1beta1.SchemeGroupVersion.Version {
+		return errored(http.StatusBadRequest, fmt.Errorf("unsupported version %s, only %s is supported", req.Kind.Version, myv1beta1.SchemeGroupVersion.Version))
+	}
+
+	arman := &myv1beta1.Arman{}
+	var allErrs field.ErrorList
+	switch req.Operation {
+	case admissionv1.Create:
+		if err := json.Unmarshal(req.Object.Raw, arman); err != nil {
+			return errored(http.StatusBadRequest, err)
+		}
+		allErrs = validation.ValidateArman(arman)
+	case admissionv1.Update:
+		oldArman := &myv1beta1.Arman{}
+		if err := json.Unmarshal(req.Object.Raw, arman); err != nil {
+			return errored(http.StatusBadRequest, err)
+		}
+		if err := json.Unmarshal(req.OldObject.Raw, oldArman); err != nil {
+			return errored(http.StatusBadRequest, err)
+		}
+		allErrs = validation.ValidateArmanUpdate(arman, oldArman)
+	default:
+		return allowed()
+	}
+
+	if len(allErrs) > 0 {
+		err := apierrors.NewInvalid(myv1beta1.Kind("Arman"), arman.Name, allErrs)
+		klog.V(4).Infof("Denied %s of arman %s/%s: %s", req.Operation, req.Namespace, req.Name, err.Error())
+		return denied(err.Status())
+	}
+	return allowed()
+}
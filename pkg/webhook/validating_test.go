@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+// testArman returns a valid Arman named web.
+func testArman() *myv1beta1.Arman {
+	return &myv1beta1.Arman{
+		TypeMeta:   metav1.TypeMeta{APIVersion: myv1beta1.SchemeGroupVersion.String(), Kind: "Arman"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: metav1.NamespaceDefault},
+		Spec: myv1beta1.ArmanSpec{
+			Workload: myv1beta1.WorkloadSpec{Name: "web", Image: "registry.example.com/web:v1"},
+			Service:  myv1beta1.ServiceSpec{Name: "web", Type: string(corev1.ServiceTypeClusterIP), Port: 80},
+		},
+	}
+}
+
+// rawArman returns arman encoded like the API server sends it.
+func rawArman(t *testing.T, arman *myv1beta1.Arman) runtime.RawExtension {
+	t.Helper()
+	if arman == nil {
+		return runtime.RawExtension{}
+	}
+	raw, err := json.Marshal(arman)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime.RawExtension{Raw: raw}
+}
+
+// review sends an AdmissionReview with req to handler and returns the
+// response.
+func review(t *testing.T, handler http.Handler, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	t.Helper()
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request:  req,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	got := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.APIVersion != admissionv1.SchemeGroupVersion.String() || got.Kind != "AdmissionReview" {
+		t.Errorf("response is a %s %s, want an %s AdmissionReview", got.APIVersion, got.Kind, admissionv1.SchemeGroupVersion)
+	}
+	if got.Response == nil {
+		t.Fatal("AdmissionReview has no response")
+	}
+	if got.Response.UID != req.UID {
+		t.Errorf("response UID = %q, want %q", got.Response.UID, req.UID)
+	}
+	return got.Response
+}
+
+func TestValidatingHandler(t *testing.T) {
+	invalid := testArman()
+	invalid.Spec.Service.Port = 0
+	invalid.Spec.Service.NodePort = 30080
+	renamed := testArman()
+	renamed.Spec.Workload.Name = "web-v2"
+
+	armanKind := metav1.GroupVersionKind{Group: myv1beta1.SchemeGroupVersion.Group, Version: myv1beta1.SchemeGroupVersion.Version, Kind: "Arman"}
+	tests := []struct {
+		name      string
+		kind      metav1.GroupVersionKind
+		operation admissionv1.Operation
+		object    *myv1beta1.Arman
+		oldObject *myv1beta1.Arman
+		// wantCauses are the fields of the causes of a denial.
+		wantCauses []string
+		wantCode   int32
+	}{
+		{
+			name:      "create valid",
+			kind:      armanKind,
+			operation: admissionv1.Create,
+			object:    testArman(),
+		},
+		{
+			name:       "create invalid",
+			kind:       armanKind,
+			operation:  admissionv1.Create,
+			object:     invalid,
+			wantCauses: []string{"spec.service.ports", "spec.service.nodePort"},
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "update valid",
+			kind:      armanKind,
+			operation: admissionv1.Update,
+			object:    testArman(),
+			oldObject: testArman(),
+		},
+		{
+			name:       "update renaming",
+			kind:       armanKind,
+			operation:  admissionv1.Update,
+			object:     renamed,
+			oldObject:  testArman(),
+			wantCauses: []string{"spec.workload.name"},
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "delete invalid",
+			kind:      armanKind,
+			operation: admissionv1.Delete,
+			oldObject: invalid,
+		},
+		{
+			name:      "old version",
+			kind:      metav1.GroupVersionKind{Group: myv1beta1.SchemeGroupVersion.Group, Version: "v1alpha1", Kind: "Arman"},
+			operation: admissionv1.Create,
+			object:    testArman(),
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "other kind",
+			kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			operation: admissionv1.Create,
+			object:    testArman(),
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := review(t, ValidatingHandler(), &admissionv1.AdmissionRequest{
+				UID:       types.UID("request-" + strings.ReplaceAll(tt.name, " ", "-")),
+				Kind:      tt.kind,
+				Name:      "web",
+				Namespace: metav1.NamespaceDefault,
+				Operation: tt.operation,
+				Object:    rawArman(t, tt.object),
+				OldObject: rawArman(t, tt.oldObject),
+			})
+
+			if tt.wantCode == 0 {
+				if !resp.Allowed {
+					t.Errorf("request denied: %v", resp.Result)
+				}
+				return
+			}
+			if resp.Allowed {
+				t.Fatal("request allowed, want it denied")
+			}
+			if resp.Result == nil || resp.Result.Code != tt.wantCode {
+				t.Fatalf("result = %v, want code %d", resp.Result, tt.wantCode)
+			}
+			if tt.wantCauses == nil {
+				return
+			}
+			if resp.Result.Reason != metav1.StatusReasonInvalid || resp.Result.Details == nil {
+				t.Fatalf("result = %v, want an Invalid status with details", resp.Result)
+			}
+			var causes []string
+			for _, cause := range resp.Result.Details.Causes {
+				causes = append(causes, cause.Field)
+			}
+			if strings.Join(causes, ",") != strings.Join(tt.wantCauses, ",") {
+				t.Errorf("causes = %v, want %v", causes, tt.wantCauses)
+			}
+		})
+	}
+}
+
+func TestValidatingHandlerBadRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantCode    int
+	}{
+		{"get", http.MethodGet, "application/json", "", http.StatusMethodNotAllowed},
+		{"yaml", http.MethodPost, "application/yaml", "request: {}", http.StatusUnsupportedMediaType},
+		{"not json", http.MethodPost, "application/json", "{", http.StatusBadRequest},
+		{"no request", http.MethodPost, "application/json", `{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, ValidatePath, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			ValidatingHandler().ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
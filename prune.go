@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+const (
+	// controllerUIDIndex is the name of the Deployment and Service informer
+	// index keyed by the UID of the controller of the object.
+	controllerUIDIndex = "controllerUID"
+
+	// ChildPruned is used as part of the Event 'reason' when a child
+	// resource that is no longer named in the Arman spec is deleted.
+	ChildPruned = "ChildPruned"
+	// MessageChildPruned is the message used for Events when a child resource
+	// is pruned
+	MessageChildPruned = "Deleted %s %q which is no longer part of the spec"
+)
+
+// indexByControllerUID indexes an object by the UID of its controller, so
+// that every child of an Arman can be found whatever its name.
+func indexByControllerUID(obj interface{}) ([]string, error) {
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, nil
+	}
+	if ownerRef := metav1.GetControllerOf(object); ownerRef != nil {
+		return []string{string(ownerRef.UID)}, nil
+	}
+	return nil, nil
+}
+
+// controlledChildren returns every Deployment and Service controlled by messi.
+func (c *Controller) controlledChildren(messi *myv1beta1.Arman) ([]*appsv1.Deployment, []*corev1.Service, error) {
+	deploymentObjs, err := c.deploymentsIndexer.ByIndex(controllerUIDIndex, string(messi.UID))
+	if err != nil {
+		return nil, nil, err
+	}
+	serviceObjs, err := c.serviceIndexer.ByIndex(controllerUIDIndex, string(messi.UID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deployments := make([]*appsv1.Deployment, 0, len(deploymentObjs))
+	for _, obj := range deploymentObjs {
+		deployments = append(deployments, obj.(*appsv1.Deployment))
+	}
+	services := make([]*corev1.Service, 0, len(serviceObjs))
+	for _, obj := range serviceObjs {
+		services = append(services, obj.(*corev1.Service))
+	}
+	return deployments, services, nil
+}
+
+// pruneChildren deletes the Deployments and Services controlled by messi
+// that are no longer named in its spec, like the old ones left behind after
+// spec.deploymentName or spec.serviceName was changed.
+func (c *Controller) pruneChildren(ctx context.Context, messi *myv1beta1.Arman) error {
+	deployments, services, err := c.controlledChildren(messi)
+	if err != nil {
+		return err
+	}
+
+	var stale []metav1.Object
+	for _, deployment := range deployments {
+		if deployment.Name != messi.Spec.Workload.Name {
+			stale = append(stale, deployment)
+		}
+	}
+	for _, svc := range services {
+		if svc.Name != messi.Spec.Service.Name {
+			stale = append(stale, svc)
+		}
+	}
+
+	for _, obj := range stale {
+		kind := childKind(obj)
+		klog.V(4).Infof("Pruning %s %s/%s of arman %s", kind, obj.GetNamespace(), obj.GetName(), messi.Name)
+		if err := c.deleteChild(ctx, obj); err != nil {
+			return err
+		}
+		c.recorder.Eventf(messi, corev1.EventTypeNormal, ChildPruned, MessageChildPruned, kind, obj.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+)
+
+func TestPruneChildren(t *testing.T) {
+	arman := newArman("web", armanUID)
+	arman.Spec.Workload.Name = "web-v2"
+	arman.Spec.Service.Name = "web"
+	c := newTestController(t, arman)
+	kubeClient := c.kubeclientset.(*k8sfake.Clientset)
+	addChildren(t, c,
+		// The Deployment under the name the Arman had before the rename.
+		&appsv1.Deployment{ObjectMeta: controlledBy("web-v1", "old-deployment-uid")},
+		&appsv1.Deployment{ObjectMeta: controlledBy("web-v2", "deployment-uid")},
+		&corev1.Service{ObjectMeta: controlledBy("web", "service-uid")},
+		// Objects the Arman does not control.
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web-v1", Namespace: "team-a", UID: "unowned-uid"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web-v1", Namespace: metav1.NamespaceDefault, UID: "unowned-service-uid"}},
+		&appsv1.Deployment{ObjectMeta: ownedBy(myv1beta1.SchemeGroupVersion.String(), "Arman", "api", "7c9d2e1f-0000-4000-8000-000000000000", true)},
+	)
+
+	if err := c.pruneChildren(context.Background(), arman); err != nil {
+		t.Fatalf("pruneChildren() = %v", err)
+	}
+
+	want := []string{"delete deployments/web-v1"}
+	if got := writes(&kubeClient.Fake); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("writes = %v, want %v", got, want)
+	}
+	if _, err := kubeClient.AppsV1().Deployments(metav1.NamespaceDefault).Get(context.Background(), "web-v1", metav1.GetOptions{}); err == nil {
+		t.Errorf("renamed deployment still exists")
+	}
+	for _, kept := range []struct {
+		namespace, name string
+	}{
+		{metav1.NamespaceDefault, "web-v2"},
+		{"team-a", "web-v1"},
+	} {
+		if _, err := kubeClient.AppsV1().Deployments(kept.namespace).Get(context.Background(), kept.name, metav1.GetOptions{}); err != nil {
+			t.Errorf("deployment %s/%s was deleted: %v", kept.namespace, kept.name, err)
+		}
+	}
+	if _, err := kubeClient.CoreV1().Services(metav1.NamespaceDefault).Get(context.Background(), "web-v1", metav1.GetOptions{}); err != nil {
+		t.Errorf("unowned service was deleted: %v", err)
+	}
+}
+
+func TestPruneChildrenUnowned(t *testing.T) {
+	arman := newArman("web", armanUID)
+	arman.Spec.Workload.Name = "web-v2"
+	arman.Spec.Service.Name = "web"
+	c := newTestController(t, arman)
+	kubeClient := c.kubeclientset.(*k8sfake.Clientset)
+	// A Deployment under the old name that someone else created after the
+	// one of the Arman was pruned, or that was never the Arman's.
+	addChildren(t, c, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web-v1", Namespace: metav1.NamespaceDefault, UID: "unowned-uid"}})
+
+	if err := c.pruneChildren(context.Background(), arman); err != nil {
+		t.Fatalf("pruneChildren() = %v", err)
+	}
+	if got := writes(&kubeClient.Fake); len(got) != 0 {
+		t.Errorf("writes = %v, want none", got)
+	}
+}
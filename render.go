@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	"github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/validation"
+	myclientscheme "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/scheme"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
+)
+
+// stringsFlag is a flag that can be given several times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// runRender runs the render subcommand with args, the arguments after its
+// name. It needs no connection to a cluster.
+func runRender(args []string) error {
+	var filenames stringsFlag
+	var output, namespace, defaultsFile string
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	klog.InitFlags(fs)
+	fs.Var(&filenames, "f", "File holding Arman manifests, - for stdin. Can be given several times; defaults to stdin.")
+	fs.StringVar(&output, "o", "yaml", "Output format, yaml or json.")
+	fs.StringVar(&namespace, "namespace", metav1.NamespaceDefault, "Namespace of the Armans that do not set one.")
+	fs.StringVar(&defaultsFile, "workload-defaults", "", "YAML file holding the workload defaults of the controller, as given to its --workload-defaults.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s render [-f FILE]... [-o yaml|json]\n\n", fs.Name())
+		fmt.Fprintln(fs.Output(), "Prints the ConfigMaps, Secrets, Deployments and Services the controller creates for the Armans in the manifests. Other objects in the manifests are skipped.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if output != "yaml" && output != "json" {
+		return fmt.Errorf("invalid output format %q, must be yaml or json", output)
+	}
+	if len(filenames) == 0 {
+		filenames = stringsFlag{"-"}
+	}
+	defaults, err := loadWorkloadDefaults(defaultsFile)
+	if err != nil {
+		return err
+	}
+
+	var armans []*myv1beta1.Arman
+	for _, filename := range filenames {
+		decoded, err := decodeArmansFile(filename)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		armans = append(armans, decoded...)
+	}
+	for _, arman := range armans {
+		if arman.Namespace == "" {
+			arman.Namespace = namespace
+		}
+	}
+	return renderArmans(os.Stdout, armans, defaults, output)
+}
+
+// decodeArmansFile decodes the Armans in the file named filename, or in
+// stdin for -, like decodeArmans. The file is closed before returning, so
+// that rendering many files does not keep all of them open.
+func decodeArmansFile(filename string) (armans []*myv1beta1.Arman, err error) {
+	if filename == "-" {
+		return decodeArmans(os.Stdin)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return decodeArmans(f)
+}
+
+// decodeArmans decodes the Armans in the YAML or JSON documents read from r,
+// of any version the clientset scheme knows, into v1beta1, defaulted the
+// way the controller defaults them. Documents holding other kinds are
+// skipped; invalid Armans are an error.
+func decodeArmans(r io.Reader) ([]*myv1beta1.Arman, error) {
+	decoder := serializer.NewCodecFactory(myclientscheme.Scheme, serializer.EnableStrict).UniversalDecoder(myv1beta1.SchemeGroupVersion)
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+
+	var armans []*myv1beta1.Arman
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return armans, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		typeMeta := &metav1.TypeMeta{}
+		if err := yaml.Unmarshal(doc, typeMeta); err != nil {
+			return nil, err
+		}
+		if gvk := typeMeta.GroupVersionKind(); gvk.GroupKind() != myv1beta1.Kind("Arman") {
+			klog.V(2).Infof("Skipping %s %s", typeMeta.APIVersion, typeMeta.Kind)
+			continue
+		}
+
+		arman := &myv1beta1.Arman{}
+		if _, _, err := decoder.Decode(doc, nil, arman); err != nil {
+			return nil, err
+		}
+		myclientscheme.Scheme.Default(arman)
+		if allErrs := validation.ValidateArman(arman); len(allErrs) > 0 {
+			return nil, apierrors.NewInvalid(myv1beta1.Kind("Arman"), arman.Name, allErrs)
+		}
+		armans = append(armans, arman)
+	}
+}
+
+// renderArmans writes the children of armans to w, as YAML documents or as
+// a JSON List. The containers get the settings of defaults, which may be
+// nil, that the Armans leave unset.
+func renderArmans(w io.Writer, armans []*myv1beta1.Arman, defaults *render.WorkloadDefaults, output string) error {
+	var objs []interface{}
+	for _, arman := range armans {
+		objs = append(objs, render.Objects(defaults.Apply(arman))...)
+	}
+
+	if output == "json" {
+		items := make([]json.RawMessage, 0, len(objs))
+		for _, obj := range objs {
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			items = append(items, data)
+		}
+		list := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      items,
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(list)
+	}
+
+	for i, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// basicV1alpha1 is pkg/render/testdata/basic.arman.yaml written as
+// v1alpha1, next to an object of another kind.
+const basicV1alpha1 = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+---
+apiVersion: arman.com/v1alpha1
+kind: Arman
+metadata:
+  name: web
+  namespace: default
+  uid: 5f3c2a8e-1b4d-4c6f-9a0e-2d7b8c9e1f00
+spec:
+  deploymentImage: nginx:1.25
+  replicas: 2
+  servicePort: 80
+  serviceTargetPort: 8080
+`
+
+func TestRenderCommand(t *testing.T) {
+	armans, err := decodeArmans(strings.NewReader(basicV1alpha1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := renderArmans(&got, armans, nil, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("pkg/render/testdata/basic.golden.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), got.String()); diff != "" {
+		t.Errorf("rendered objects differ from the golden file (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderCommandRejectsInvalidArmans(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "unknown field",
+			input: "apiVersion: arman.com/v1beta1\nkind: Arman\nmetadata:\n  name: web\nspec:\n  workload:\n    image: nginx\n    imagee: nginx\n  service:\n    port: 80\n",
+		},
+		{
+			name:  "invalid spec",
+			input: "apiVersion: arman.com/v1beta1\nkind: Arman\nmetadata:\n  name: web\nspec:\n  workload:\n    image: nginx\n  service:\n    port: 0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeArmans(strings.NewReader(tt.input)); err == nil {
+				t.Error("decodeArmans() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestDecodeArmansFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "armans.yaml")
+	if err := os.WriteFile(filename, []byte(basicV1alpha1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	armans, err := decodeArmansFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(armans) != 1 || armans[0].Name != "web" {
+		t.Errorf("decodeArmansFile() = %v, want the Arman web", armans)
+	}
+
+	if _, err := decodeArmansFile(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("decodeArmansFile() of a missing file = %v, want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// serveHTTP serves handler on addr until ctx is done. name says what is
+// served, for the logs.
+func serveHTTP(ctx context.Context, name, addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("Error shutting down %s server: %s", name, err.Error())
+		}
+	}()
+
+	klog.Infof("Serving %s on %s", name, addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.Fatalf("Error serving %s: %s", name, err.Error())
+	}
+}
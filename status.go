@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
+)
+
+// Reasons used in the conditions of an Arman.
+const (
+	ReasonAvailable                = "Available"
+	ReasonNotAvailable             = "NotAvailable"
+	ReasonRollingOut               = "RollingOut"
+	ReasonRolloutComplete          = "RolloutComplete"
+	ReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+	ReasonSyncFailed               = "SyncFailed"
+	ReasonSynced                   = "Synced"
+	ReasonApplyConflict            = "ApplyConflict"
+	ReasonResourceExists           = ErrResourceExists
+	ReasonNoConflict               = "NoConflict"
+)
+
+// resourceExistsError is returned when a child resource of an Arman exists
+// but is controlled by someone else.
+type resourceExistsError struct {
+	name string
+}
+
+func (e resourceExistsError) Error() string {
+	return fmt.Sprintf(MessageResourceExists, e.name)
+}
+
+// applyConflictError is returned when applying a child of an Arman
+// conflicts with the fields of another field manager.
+type applyConflictError struct {
+	err error
+}
+
+func (e applyConflictError) Error() string {
+	return e.err.Error()
+}
+
+func (e applyConflictError) Unwrap() error {
+	return e.err
+}
+
+// updateArmanStatus writes the status computed from the children of messi and
+// the outcome of syncing them, syncErr, through the status subresource. Nothing
+// is written if the status did not change.
+func (c *Controller) updateArmanStatus(ctx context.Context, messi *myv1beta1.Arman, deployment *appsv1.Deployment, svc *corev1.Service, syncErr error) error {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+	messiCopy := messi.DeepCopy()
+	setArmanStatus(&messiCopy.Status, messi.Generation, deployment, svc, syncErr)
+	// The scale subresource reads the selector of the pods from the status,
+	// which is the one of the Deployment we render.
+	messiCopy.Status.Selector = labels.SelectorFromSet(render.Labels(messi)).String()
+	if equality.Semantic.DeepEqual(messi.Status, messiCopy.Status) {
+		return nil
+	}
+
+	klog.V(4).Infof("Updating status of arman %s/%s", messi.Namespace, messi.Name)
+	updated, err := c.sampleclientset.ArmanV1beta1().Armans(messi.Namespace).UpdateStatus(ctx, messiCopy, metav1.UpdateOptions{DryRun: c.dryRun()})
+	if c.DryRun && err == nil {
+		logDryRun(messi, "Arman status", messi.Name, &messi.Status, &updated.Status)
+	}
+	return err
+}
+
+// setArmanStatus fills status from the children of an Arman of the given
+// generation. The children are nil if they could not be synced.
+func setArmanStatus(status *myv1beta1.ArmanStatus, generation int64, deployment *appsv1.Deployment, svc *corev1.Service, syncErr error) {
+	status.ObservedGeneration = generation
+	status.DeploymentName, status.ServiceName, status.Endpoint = "", "", ""
+	status.Replicas, status.AvailableReplicas, status.ReadyReplicas, status.UpdatedReplicas = 0, 0, 0, 0
+	if deployment != nil {
+		status.DeploymentName = deployment.Name
+		status.Replicas = deployment.Status.Replicas
+		status.AvailableReplicas = deployment.Status.AvailableReplicas
+		status.ReadyReplicas = deployment.Status.ReadyReplicas
+		status.UpdatedReplicas = deployment.Status.UpdatedReplicas
+	}
+	if svc != nil {
+		status.ServiceName = svc.Name
+		status.Endpoint = serviceEndpoint(svc)
+	}
+
+	setCondition := func(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	_, exists := syncErr.(resourceExistsError)
+	switch {
+	case errors.IsConflict(syncErr):
+		setCondition(myv1beta1.ConditionResourceConflict, metav1.ConditionTrue, ReasonApplyConflict, syncErr.Error())
+	case exists:
+		setCondition(myv1beta1.ConditionResourceConflict, metav1.ConditionTrue, ReasonResourceExists, syncErr.Error())
+	default:
+		setCondition(myv1beta1.ConditionResourceConflict, metav1.ConditionFalse, ReasonNoConflict, "")
+	}
+
+	rolledOut, progressing, deadlineExceeded := deploymentRollout(deployment)
+	switch {
+	case deployment == nil:
+		setCondition(myv1beta1.ConditionProgressing, metav1.ConditionUnknown, ReasonSyncFailed, "Deployment has not been synced")
+	case deadlineExceeded:
+		setCondition(myv1beta1.ConditionProgressing, metav1.ConditionFalse, ReasonProgressDeadlineExceeded, deploymentProgressingMessage(deployment))
+	case progressing:
+		setCondition(myv1beta1.ConditionProgressing, metav1.ConditionTrue, ReasonRollingOut, deploymentProgressingMessage(deployment))
+	default:
+		setCondition(myv1beta1.ConditionProgressing, metav1.ConditionFalse, ReasonRolloutComplete, deploymentProgressingMessage(deployment))
+	}
+
+	switch {
+	case syncErr != nil:
+		setCondition(myv1beta1.ConditionDegraded, metav1.ConditionTrue, ReasonSyncFailed, syncErr.Error())
+	case deadlineExceeded:
+		setCondition(myv1beta1.ConditionDegraded, metav1.ConditionTrue, ReasonProgressDeadlineExceeded, deploymentProgressingMessage(deployment))
+	default:
+		setCondition(myv1beta1.ConditionDegraded, metav1.ConditionFalse, ReasonSynced, "")
+	}
+
+	switch {
+	case syncErr != nil:
+		setCondition(myv1beta1.ConditionReady, metav1.ConditionFalse, ReasonSyncFailed, syncErr.Error())
+	case rolledOut && svc != nil:
+		setCondition(myv1beta1.ConditionReady, metav1.ConditionTrue, ReasonAvailable, fmt.Sprintf("%d of %d replicas are available", status.AvailableReplicas, desiredReplicas(deployment)))
+	default:
+		setCondition(myv1beta1.ConditionReady, metav1.ConditionFalse, ReasonNotAvailable, fmt.Sprintf("%d of %d replicas are available", status.AvailableReplicas, desiredReplicas(deployment)))
+	}
+}
+
+// deploymentRollout reports whether deployment is fully rolled out and
+// available, whether it is still rolling out and whether it exceeded its
+// progress deadline.
+func deploymentRollout(deployment *appsv1.Deployment) (rolledOut, progressing, deadlineExceeded bool) {
+	if deployment == nil {
+		return false, false, false
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded" {
+			deadlineExceeded = true
+		}
+	}
+	desired := desiredReplicas(deployment)
+	rolledOut = deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas >= desired &&
+		deployment.Status.Replicas == deployment.Status.UpdatedReplicas &&
+		deployment.Status.AvailableReplicas >= desired
+	return rolledOut, !rolledOut && !deadlineExceeded, deadlineExceeded
+}
+
+// deploymentProgressingMessage describes the rollout of deployment.
+func deploymentProgressingMessage(deployment *appsv1.Deployment) string {
+	return fmt.Sprintf("%d of %d replicas are updated", deployment.Status.UpdatedReplicas, desiredReplicas(deployment))
+}
+
+func desiredReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment == nil {
+		return 0
+	}
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// serviceEndpoint returns the address svc can be reached at: the load
+// balancer ingress if one was provisioned, the cluster IP otherwise, and the
+// cluster DNS name for a headless Service.
+func serviceEndpoint(svc *corev1.Service) string {
+	if len(svc.Spec.Ports) == 0 {
+		return ""
+	}
+	port := strconv.Itoa(int(svc.Spec.Ports[0].Port))
+
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				return net.JoinHostPort(ingress.Hostname, port)
+			}
+			if ingress.IP != "" {
+				return net.JoinHostPort(ingress.IP, port)
+			}
+		}
+	}
+	if svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone {
+		return net.JoinHostPort(svc.Spec.ClusterIP, port)
+	}
+	return net.JoinHostPort(fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace), port)
+}
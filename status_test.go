@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	myv1beta1 "github.com/sheikh-arman/crd-controller/pkg/apis/arman.com/v1beta1"
+	myfake "github.com/sheikh-arman/crd-controller/pkg/client/clientset/versioned/fake"
+	"github.com/sheikh-arman/crd-controller/pkg/render"
+)
+
+// testDeployment returns a Deployment of generation 2 wanting 3 replicas,
+// of which updated are updated and available available.
+func testDeployment(updated, available int32, conditions ...appsv1.DeploymentCondition) *appsv1.Deployment {
+	replicas := int32(3)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: metav1.NamespaceDefault, Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			UpdatedReplicas:    updated,
+			ReadyReplicas:      available,
+			AvailableReplicas:  available,
+			Conditions:         conditions,
+		},
+	}
+}
+
+func testService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: metav1.NamespaceDefault},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: "10.0.0.10",
+			Ports:     []corev1.ServicePort{{Port: 80}},
+		},
+	}
+}
+
+func TestSetArmanStatus(t *testing.T) {
+	applyConflict := applyConflictError{err: apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web", fmt.Errorf("conflict with \"kubectl\""))}
+	deadlineExceeded := appsv1.DeploymentCondition{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded",
+	}
+
+	type condition struct {
+		status metav1.ConditionStatus
+		reason string
+	}
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		svc        *corev1.Service
+		syncErr    error
+		want       map[string]condition
+	}{
+		{
+			name:       "rolled out",
+			deployment: testDeployment(3, 3),
+			svc:        testService(),
+			want: map[string]condition{
+				myv1beta1.ConditionReady:            {metav1.ConditionTrue, ReasonAvailable},
+				myv1beta1.ConditionProgressing:      {metav1.ConditionFalse, ReasonRolloutComplete},
+				myv1beta1.ConditionDegraded:         {metav1.ConditionFalse, ReasonSynced},
+				myv1beta1.ConditionResourceConflict: {metav1.ConditionFalse, ReasonNoConflict},
+			},
+		},
+		{
+			name:       "rolling out",
+			deployment: testDeployment(1, 2),
+			svc:        testService(),
+			want: map[string]condition{
+				myv1beta1.ConditionReady:            {metav1.ConditionFalse, ReasonNotAvailable},
+				myv1beta1.ConditionProgressing:      {metav1.ConditionTrue, ReasonRollingOut},
+				myv1beta1.ConditionDegraded:         {metav1.ConditionFalse, ReasonSynced},
+				myv1beta1.ConditionResourceConflict: {metav1.ConditionFalse, ReasonNoConflict},
+			},
+		},
+		{
+			name:       "rolled out without a service",
+			deployment: testDeployment(3, 3),
+			want: map[string]condition{
+				myv1beta1.ConditionReady:       {metav1.ConditionFalse, ReasonNotAvailable},
+				myv1beta1.ConditionProgressing: {metav1.ConditionFalse, ReasonRolloutComplete},
+			},
+		},
+		{
+			name:       "progress deadline exceeded",
+			deployment: testDeployment(1, 2, deadlineExceeded),
+			svc:        testService(),
+			want: map[string]condition{
+				myv1beta1.ConditionReady:       {metav1.ConditionFalse, ReasonNotAvailable},
+				myv1beta1.ConditionProgressing: {metav1.ConditionFalse, ReasonProgressDeadlineExceeded},
+				myv1beta1.ConditionDegraded:    {metav1.ConditionTrue, ReasonProgressDeadlineExceeded},
+			},
+		},
+		{
+			name:    "sync failed before the children",
+			syncErr: fmt.Errorf("connection refused"),
+			want: map[string]condition{
+				myv1beta1.ConditionReady:            {metav1.ConditionFalse, ReasonSyncFailed},
+				myv1beta1.ConditionProgressing:      {metav1.ConditionUnknown, ReasonSyncFailed},
+				myv1beta1.ConditionDegraded:         {metav1.ConditionTrue, ReasonSyncFailed},
+				myv1beta1.ConditionResourceConflict: {metav1.ConditionFalse, ReasonNoConflict},
+			},
+		},
+		{
+			name:       "apply conflict",
+			deployment: testDeployment(3, 3),
+			syncErr:    applyConflict,
+			want: map[string]condition{
+				myv1beta1.ConditionReady:            {metav1.ConditionFalse, ReasonSyncFailed},
+				myv1beta1.ConditionDegraded:         {metav1.ConditionTrue, ReasonSyncFailed},
+				myv1beta1.ConditionResourceConflict: {metav1.ConditionTrue, ReasonApplyConflict},
+			},
+		},
+		{
+			name:    "child controlled by someone else",
+			syncErr: resourceExistsError{name: "web"},
+			want: map[string]condition{
+				myv1beta1.ConditionReady:            {metav1.ConditionFalse, ReasonSyncFailed},
+				myv1beta1.ConditionResourceConflict: {metav1.ConditionTrue, ReasonResourceExists},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &myv1beta1.ArmanStatus{}
+			setArmanStatus(status, 4, tt.deployment, tt.svc, tt.syncErr)
+
+			if status.ObservedGeneration != 4 {
+				t.Errorf("observedGeneration = %d, want 4", status.ObservedGeneration)
+			}
+			for _, conditionType := range []string{myv1beta1.ConditionReady, myv1beta1.ConditionProgressing, myv1beta1.ConditionDegraded, myv1beta1.ConditionResourceConflict} {
+				got := meta.FindStatusCondition(status.Conditions, conditionType)
+				if got == nil {
+					t.Errorf("condition %s not set", conditionType)
+					continue
+				}
+				if got.ObservedGeneration != 4 {
+					t.Errorf("condition %s has observedGeneration %d, want 4", conditionType, got.ObservedGeneration)
+				}
+				want, ok := tt.want[conditionType]
+				if !ok {
+					continue
+				}
+				if got.Status != want.status || got.Reason != want.reason {
+					t.Errorf("condition %s = %s/%s, want %s/%s", conditionType, got.Status, got.Reason, want.status, want.reason)
+				}
+			}
+		})
+	}
+}
+
+func TestSetArmanStatusChildren(t *testing.T) {
+	status := &myv1beta1.ArmanStatus{}
+	setArmanStatus(status, 1, testDeployment(3, 2), testService(), nil)
+
+	want := myv1beta1.ArmanStatus{
+		ObservedGeneration: 1,
+		DeploymentName:     "web",
+		ServiceName:        "web",
+		Endpoint:           "10.0.0.10:80",
+		Replicas:           3,
+		AvailableReplicas:  2,
+		ReadyReplicas:      2,
+		UpdatedReplicas:    3,
+	}
+	status.Conditions = nil
+	if !equality.Semantic.DeepEqual(*status, want) {
+		t.Errorf("status = %+v, want %+v", *status, want)
+	}
+
+	// Children that could not be synced leave nothing of the previous
+	// status behind.
+	setArmanStatus(status, 2, nil, nil, fmt.Errorf("connection refused"))
+	want = myv1beta1.ArmanStatus{ObservedGeneration: 2}
+	status.Conditions = nil
+	if !equality.Semantic.DeepEqual(*status, want) {
+		t.Errorf("status = %+v, want %+v", *status, want)
+	}
+}
+
+// TestUpdateArmanStatusScale checks the fields the scale subresource reads
+// from the status.
+func TestUpdateArmanStatusScale(t *testing.T) {
+	arman := syncedArman()
+	c := newTestController(t, arman)
+	armanClient := c.sampleclientset.(*myfake.Clientset)
+	if err := armanClient.Tracker().Add(arman); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.updateArmanStatus(context.Background(), arman, testDeployment(3, 2), testService(), nil); err != nil {
+		t.Fatalf("updateArmanStatus() = %v", err)
+	}
+	updated, err := armanClient.ArmanV1beta1().Armans(arman.Namespace).Get(context.Background(), arman.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The selector is the one of the rendered Deployment, as a string.
+	wantSelector := labels.SelectorFromSet(render.Deployment(arman).Spec.Selector.MatchLabels).String()
+	if updated.Status.Selector != wantSelector || updated.Status.Selector != "app=arman,controller=web" {
+		t.Errorf("status.selector = %q, want %q", updated.Status.Selector, wantSelector)
+	}
+	if updated.Status.Replicas != 3 {
+		t.Errorf("status.replicas = %d, want the 3 of the Deployment", updated.Status.Replicas)
+	}
+}
+
+func TestSetArmanStatusTransitions(t *testing.T) {
+	status := &myv1beta1.ArmanStatus{}
+	setArmanStatus(status, 1, testDeployment(1, 2), testService(), nil)
+
+	// Conditions keep their transition time until their status changes.
+	past := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	for i := range status.Conditions {
+		status.Conditions[i].LastTransitionTime = past
+	}
+
+	setArmanStatus(status, 2, testDeployment(3, 3), testService(), nil)
+
+	tests := []struct {
+		conditionType string
+		status        metav1.ConditionStatus
+		transitioned  bool
+	}{
+		{myv1beta1.ConditionReady, metav1.ConditionTrue, true},
+		{myv1beta1.ConditionProgressing, metav1.ConditionFalse, true},
+		{myv1beta1.ConditionDegraded, metav1.ConditionFalse, false},
+		{myv1beta1.ConditionResourceConflict, metav1.ConditionFalse, false},
+	}
+	for _, tt := range tests {
+		got := meta.FindStatusCondition(status.Conditions, tt.conditionType)
+		if got == nil {
+			t.Fatalf("condition %s not set", tt.conditionType)
+		}
+		if got.Status != tt.status {
+			t.Errorf("condition %s = %s, want %s", tt.conditionType, got.Status, tt.status)
+		}
+		if transitioned := !got.LastTransitionTime.Equal(&past); transitioned != tt.transitioned {
+			t.Errorf("condition %s transitioned = %v, want %v", tt.conditionType, transitioned, tt.transitioned)
+		}
+		if got.ObservedGeneration != 2 {
+			t.Errorf("condition %s has observedGeneration %d, want 2", tt.conditionType, got.ObservedGeneration)
+		}
+	}
+}